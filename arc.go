@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type status int
@@ -18,6 +19,9 @@ const (
 	ChangesPlanned
 	Accepted
 	NeedsRevision
+	// Abandoned revisions are not listed by arc, they only show after an
+	// abandon until the next refresh.
+	Abandoned
 )
 
 var stringToStatus = map[string]status{
@@ -26,6 +30,7 @@ var stringToStatus = map[string]status{
 	"Changes Planned": ChangesPlanned,
 	"Accepted":        Accepted,
 	"Needs Revision":  NeedsRevision,
+	"Abandoned":       Abandoned,
 }
 
 func (s status) String() string {
@@ -40,11 +45,31 @@ func (s status) String() string {
 		return colorCyan + "Accepted" + colorReset
 	case NeedsRevision:
 		return colorRed + "Needs Revision" + colorReset
+	case Abandoned:
+		return colorGray + "Abandoned" + colorReset
 	default:
 		panic(fmt.Sprintf("Unknown status: %d", s))
 	}
 }
 
+// color returns the color String gives to the status.
+func (s status) color() string {
+	switch s {
+	case NeedsReview:
+		return colorYellow
+	case Draft:
+		return colorGreen
+	case ChangesPlanned, NeedsRevision:
+		return colorRed
+	case Accepted:
+		return colorCyan
+	case Abandoned:
+		return colorGray
+	default:
+		return ""
+	}
+}
+
 type diff struct {
 	status  status
 	id      string
@@ -58,20 +83,94 @@ func (d diff) String() string {
 type diffPanel struct {
 	*tui.ListPanel[diff]
 	diff *diff
+	// changed holds when the status of a revision last changed on refresh.
+	changed map[string]time.Time
+	// loading is set until the revisions are listed, the items being the cached ones.
+	loading bool
+	loadErr error
 }
 
 func (dp *diffPanel) Draw(_ bool) string {
+	if text, ok := placeholder("revisions", dp.loading, dp.loadErr); ok && len(dp.Items) == 0 {
+		return text
+	}
 	var buffer bytes.Buffer
 	for i, item := range dp.Items {
 		selected := ""
 		if dp.Selected == i {
 			selected = colorRed + "*" + colorReset
 		}
-		buffer.WriteString(fmt.Sprintf("%s %s\n", selected, item.String()))
+		changed := " "
+		if at, ok := dp.changed[item.id]; ok && time.Since(at) < highlightDuration {
+			changed = colorYellow + "!" + colorReset
+		}
+		buffer.WriteString(fmt.Sprintf("%s%s %s\n", selected, changed, item.String()))
+	}
+	if dp.loading {
+		buffer.WriteString("\nRefreshing the cached revisions…")
 	}
 	return buffer.String()
 }
 
+// setItems replaces the revisions, keeping the selected one, and returns the
+// ids of the revisions whose status changed.
+func (dp *diffPanel) setItems(diffs []diff) []string {
+	previous := map[string]status{}
+	for _, d := range dp.Items {
+		previous[d.id] = d.status
+	}
+	var changed []string
+	for _, d := range diffs {
+		if s, ok := previous[d.id]; ok && s != d.status {
+			dp.changed[d.id] = time.Now()
+			changed = append(changed, d.id)
+		}
+	}
+
+	selected := 0
+	for i, d := range diffs {
+		if d.id == dp.diff.id {
+			selected = i
+		}
+	}
+	dp.Items = diffs
+	dp.Selected = selected
+	if len(dp.Items) > 0 {
+		*dp.diff = dp.Items[dp.Selected]
+	}
+	return changed
+}
+
+// setStatus changes the status of the listed revision id and highlights it.
+func (dp *diffPanel) setStatus(id string, s status) {
+	for i, d := range dp.Items {
+		if d.id != id {
+			continue
+		}
+		dp.Items[i].status = s
+		dp.changed[id] = time.Now()
+		if dp.diff.id == id {
+			dp.diff.status = s
+		}
+	}
+}
+
+// selectRevision selects the revision id and reports whether it is listed.
+func (dp *diffPanel) selectRevision(id string) bool {
+	n, ok := revisionNumber(id)
+	if !ok {
+		return false
+	}
+	for i, d := range dp.Items {
+		if m, ok := revisionNumber(d.id); ok && m == n {
+			dp.Selected = i
+			*dp.diff = d
+			return true
+		}
+	}
+	return false
+}
+
 func (dp *diffPanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
 	handled, redraw = dp.ListPanel.Update(msg)
 	if len(dp.Items) > 0 && dp.Selected >= 0 && dp.Selected < len(dp.Items) {
@@ -101,31 +200,12 @@ func parseDiff(line string) (diff, bool) {
 	return diff{status: status, id: id, message: message}, true
 }
 
-func getDiff() ([]diff, error) {
+// runArc runs arc with args. In dev mode it only describes the command.
+func runArc(args ...string) ([]byte, error) {
 	if isDevMode() {
-		return []diff{{
-			status:  2,
-			id:      "1",
-			message: "1",
-		}, {
-			status:  1,
-			id:      "2",
-			message: "2",
-		}}, nil
-	}
-	cmd := exec.Command("arc", "list")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run 'arc list' command: %w", err)
-	}
-	lines := strings.Split(string(output), "\n")
-	var diffs []diff
-	for _, line := range lines {
-		if d, ok := parseDiff(line); ok {
-			diffs = append(diffs, d)
-		}
+		return []byte("Would run: arc " + strings.Join(args, " ") + "\n"), nil
 	}
-	return diffs, nil
+	return exec.Command("arc", args...).CombinedOutput()
 }
 
 func newDiffPanel(name string, diffs []diff) diffPanel {
@@ -137,6 +217,7 @@ func newDiffPanel(name string, diffs []diff) diffPanel {
 			},
 			Items: diffs,
 		},
-		diff: &diff{},
+		diff:    &diff{},
+		changed: map[string]time.Time{},
 	}
 }
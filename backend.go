@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ReviewBackend is the code review service the revisions are sent to.
+type ReviewBackend interface {
+	// Name identifies the backend in the configuration and the history.
+	Name() string
+	// List returns the open revisions of the user.
+	List() ([]diff, error)
+	// Create sends the from..on range for review. The first line of message
+	// is the title, the rest the summary.
+	Create(from, on, message string, flags []string) ([]byte, error)
+	// Update replaces the revision id with the from..on range, message
+	// describing the new version.
+	Update(id, from, on, message string, flags []string) ([]byte, error)
+	// Land merges the revision id.
+	Land(id string) ([]byte, error)
+}
+
+// newBackend returns the backend chosen by the configuration, Phabricator by default.
+func newBackend(cfg config) (ReviewBackend, error) {
+	switch cfg.Backend {
+	case "", phabricatorName:
+		return phabricatorBackend{}, nil
+	case githubName:
+		return newGitHubBackend(cfg.GitHub)
+	default:
+		return nil, fmt.Errorf("unknown backend %q in %s", cfg.Backend, configFile)
+	}
+}
+
+// mockDiffs are the revisions listed in dev mode.
+func mockDiffs() []diff {
+	return []diff{{
+		status:  2,
+		id:      "1",
+		message: "1",
+	}, {
+		status:  1,
+		id:      "2",
+		message: "2",
+	}}
+}
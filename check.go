@@ -0,0 +1,127 @@
+package main
+
+import (
+	"app/tui"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type checkResult struct {
+	check  checkConfig
+	output string
+	err    error
+}
+
+// checkRun holds the results of the checks run against one commit.
+type checkRun struct {
+	hash       string
+	results    []checkResult
+	overridden bool
+}
+
+func (r *checkRun) passed() bool {
+	for _, result := range r.results {
+		if result.err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// arcFlags returns the arc flags skipping the steps already covered by the checks.
+func (r *checkRun) arcFlags() []string {
+	var lint, unit bool
+	for _, result := range r.results {
+		switch result.check.Kind {
+		case "lint":
+			lint = true
+		case "unit":
+			unit = true
+		}
+	}
+	var flags []string
+	if lint {
+		flags = append(flags, "--nolint")
+	}
+	if unit {
+		flags = append(flags, "--nounit")
+	}
+	return flags
+}
+
+// runChecks runs every check in a temporary worktree checked out at hash.
+func runChecks(checks []checkConfig, hash string) (*checkRun, error) {
+	dir, err := os.MkdirTemp("", "bow-check-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if output, err := exec.Command("git", "worktree", "add", "--detach", dir, hash).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to create worktree at %s: %w: %s", hash, err, output)
+	}
+	defer func() {
+		if output, err := exec.Command("git", "worktree", "remove", "--force", dir).CombinedOutput(); err != nil {
+			slog.Warn("failed to remove check worktree", "dir", dir, "error", err, "output", string(output))
+		}
+	}()
+
+	run := &checkRun{hash: hash}
+	for _, check := range checks {
+		cmd := exec.Command("sh", "-c", check.Command)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		slog.Info("ran check", "name", check.Name, "commit", hash, "error", err)
+		run.results = append(run.results, checkResult{check: check, output: string(output), err: err})
+	}
+	return run, nil
+}
+
+type checkPanel struct {
+	*tui.InfoPanel
+}
+
+// setRunning shows the checks running against hash.
+func (cp *checkPanel) setRunning(checks []checkConfig, hash string) {
+	lines := []string{fmt.Sprintf("Running the checks on %s…", short(hash)), ""}
+	for _, check := range checks {
+		lines = append(lines, "… "+check.Name)
+	}
+	cp.Lines = lines
+}
+
+// setRun shows the results of run, failed checks with their output.
+func (cp *checkPanel) setRun(run *checkRun) {
+	var lines []string
+	for _, result := range run.results {
+		if result.err == nil {
+			lines = append(lines, fmt.Sprintf("%s✓%s %s", colorGreen, colorReset, result.check.Name))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s✗%s %s: %v", colorRed, colorReset, result.check.Name, result.err))
+		for line := range strings.SplitSeq(strings.TrimRight(result.output, "\n"), "\n") {
+			lines = append(lines, "    "+line)
+		}
+	}
+	lines = append(lines, "")
+	if run.passed() {
+		lines = append(lines, "All checks passed. Ctrl+S: submit")
+	} else {
+		lines = append(lines, "Checks failed. !: submit anyway")
+	}
+	cp.Lines = lines
+}
+
+func newCheckPanel(name string) checkPanel {
+	return checkPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
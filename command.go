@@ -5,4 +5,10 @@ type command string
 const (
 	Update command = "Update"
 	Create command = "Create"
+	Inbox  command = "Inbox"
+	// Land and Edit are not modes, they only name the landing operations
+	// and the edits of revisions, like accepting or abandoning them, in the
+	// history.
+	Land command = "Land"
+	Edit command = "Edit"
 )
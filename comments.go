@@ -0,0 +1,215 @@
+package main
+
+import (
+	"app/tui"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reviewComment is a comment left on a revision, inline when path is set.
+type reviewComment struct {
+	path       string
+	line       int
+	authorPHID string
+	author     string
+	date       time.Time
+	text       string
+	done       bool
+}
+
+type transaction struct {
+	Type        string `json:"type"`
+	AuthorPHID  string `json:"authorPHID"`
+	DateCreated int64  `json:"dateCreated"`
+	Comments    []struct {
+		Removed bool `json:"removed"`
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	} `json:"comments"`
+	Fields struct {
+		Path   string `json:"path"`
+		Line   int    `json:"line"`
+		IsDone bool   `json:"isDone"`
+	} `json:"fields"`
+}
+
+type transactionPage struct {
+	Data   []transaction `json:"data"`
+	Cursor searchCursor  `json:"cursor"`
+}
+
+// parseTransactions extracts the comments of a transaction.search page.
+func parseTransactions(page transactionPage) []reviewComment {
+	var comments []reviewComment
+	for _, xact := range page.Data {
+		if xact.Type != "inline" && xact.Type != "comment" {
+			continue
+		}
+		for _, c := range xact.Comments {
+			if c.Removed {
+				continue
+			}
+			comments = append(comments, reviewComment{
+				path:       xact.Fields.Path,
+				line:       xact.Fields.Line,
+				authorPHID: xact.AuthorPHID,
+				date:       time.Unix(xact.DateCreated, 0),
+				text:       c.Content.Raw,
+				done:       xact.Fields.IsDone,
+			})
+		}
+	}
+	return comments
+}
+
+// getComments fetches the comments of the revision with the given id, like D12345.
+func getComments(id string) ([]reviewComment, error) {
+	if isDevMode() {
+		return []reviewComment{
+			{author: "alice", date: time.Unix(1700000000, 0), text: "Looks good overall"},
+			{path: "main.go", line: 12, author: "alice", date: time.Unix(1700000100, 0), text: "Handle the error", done: true},
+			{path: "main.go", line: 40, author: "bob", date: time.Unix(1700000200, 0), text: "Rename this"},
+		}, nil
+	}
+
+	var comments []reviewComment
+	params := map[string]any{"objectIdentifier": id}
+	for {
+		var page transactionPage
+		if err := callConduit("transaction.search", params, &page); err != nil {
+			return nil, err
+		}
+		comments = append(comments, parseTransactions(page)...)
+		if page.Cursor.After == nil {
+			break
+		}
+		params["after"] = *page.Cursor.After
+	}
+
+	var phids []string
+	for _, c := range comments {
+		phids = append(phids, c.authorPHID)
+	}
+	names, err := getUsernames(phids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		comments[i].author = names[comments[i].authorPHID]
+	}
+	return comments, nil
+}
+
+// formatComments renders comments grouped by file and line, general comments first.
+func formatComments(comments []reviewComment) []string {
+	sorted := append([]reviewComment{}, comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].path != sorted[j].path {
+			return sorted[i].path < sorted[j].path
+		}
+		return sorted[i].line < sorted[j].line
+	})
+
+	var lines []string
+	path := "\x00"
+	for _, c := range sorted {
+		if c.path != path {
+			path = c.path
+			if path == "" {
+				lines = append(lines, colorCyan+"General"+colorReset)
+			} else {
+				lines = append(lines, colorCyan+path+colorReset)
+			}
+		}
+		location, mark := "    ", " "
+		if c.path != "" {
+			location = fmt.Sprintf("%4d", c.line)
+			mark = colorRed + "✗" + colorReset
+			if c.done {
+				mark = colorGreen + "✓" + colorReset
+			}
+		}
+		text := strings.ReplaceAll(strings.TrimSpace(c.text), "\n", " ")
+		lines = append(lines, fmt.Sprintf("%s %s %s %s: %s", location, mark, c.author, c.date.Format("2006-01-02"), text))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No comments")
+	}
+	return lines
+}
+
+// loadedComments are the comments of a revision, or why they could not be fetched.
+type loadedComments struct {
+	comments []reviewComment
+	err      error
+}
+
+// commentsPanel shows the review comments of the selected revision.
+type commentsPanel struct {
+	*tui.InfoPanel
+	revision *diff
+	// shown is the revision the lines describe.
+	shown string
+	// loaded caches the comments of each revision, fetched by load in the
+	// background when missing.
+	loaded map[string]loadedComments
+	load   func(id string)
+}
+
+func (cp *commentsPanel) Draw(active bool) string {
+	id := cp.revision.id
+	if id == "" {
+		return "No revision selected"
+	}
+	loaded, ok := cp.loaded[id]
+	if !ok {
+		cp.load(id)
+		return fmt.Sprintf("Loading the comments of %s…", id)
+	}
+	if id != cp.shown {
+		cp.shown = id
+		if loaded.err != nil {
+			slog.Error("failed to get comments", "revision", id, "error", loaded.err)
+			cp.Lines = []string{fmt.Sprintf("Failed to get comments of %s: %v", id, loaded.err)}
+		} else {
+			cp.setComments(id, loaded.comments)
+		}
+	}
+	return cp.InfoPanel.Draw(active)
+}
+
+// forget drops the cached comments, fetched again when shown.
+func (cp *commentsPanel) forget() {
+	cp.loaded = nil
+	cp.shown = ""
+}
+
+// setComments shows the comments of the revision id.
+func (cp *commentsPanel) setComments(id string, comments []reviewComment) {
+	var inline, done int
+	for _, c := range comments {
+		if c.path != "" {
+			inline++
+		}
+		if c.done {
+			done++
+		}
+	}
+	lines := []string{fmt.Sprintf("%s: %d/%d inline comments done", id, done, inline), ""}
+	cp.Lines = append(lines, formatComments(comments)...)
+}
+
+func newCommentsPanel(name string) commentsPanel {
+	return commentsPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
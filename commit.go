@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/go-git/go-git/v6"
@@ -20,23 +21,70 @@ func (c commit) String() string {
 	return fmt.Sprintf("%s%s%s: %s", colorYellow, c.Hash.String()[:6], colorReset, msg)
 }
 
+// revisionNumber returns the number of a revision id like D00123.
+func revisionNumber(id string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "D"))
+	return n, err == nil && strings.HasPrefix(id, "D")
+}
+
+// revisionLabel names the revision c belongs to, from its trailer, in the
+// color of its status among diffs. A revision missing from diffs, landed or
+// of another author, is not colored, and a commit without revision is marked.
+func revisionLabel(c commit, diffs []diff) string {
+	const width = 7
+	revision := revisionOf(c.Message)
+	n, ok := revisionNumber(revision)
+	if !ok {
+		return fmt.Sprintf("%s%-*s%s", colorRed, width, "-", colorReset)
+	}
+	for _, d := range diffs {
+		if m, ok := revisionNumber(d.id); ok && m == n {
+			return fmt.Sprintf("%s%-*s%s", d.status.color(), width, revision, colorReset)
+		}
+	}
+	return fmt.Sprintf("%-*s", width, revision)
+}
+
+// maxCommits is the number of commits listed in the commit panels.
+const maxCommits = 20
+
 type commitPanel struct {
 	*tui.ListPanel[commit]
 	commit *commit
+	graph  []string
+	// revisions are the listed revisions, to annotate the commits with their status.
+	revisions *[]diff
+	// loading is set until the commits are read in the background.
+	loading bool
+	loadErr error
 }
 
 func (cp *commitPanel) Draw(_ bool) string {
+	if text, ok := placeholder("commits", cp.loading, cp.loadErr); ok && len(cp.Items) == 0 {
+		return text
+	}
 	var buffer bytes.Buffer
 	for i, item := range cp.Items {
 		selected := ""
 		if cp.Selected == i {
 			selected = colorRed + "*" + colorReset
 		}
-		buffer.WriteString(fmt.Sprintf("%s %s\n", selected, item.String()))
+		graph := ""
+		if i < len(cp.graph) {
+			graph = colorCyan + cp.graph[i] + colorReset + " "
+		}
+		buffer.WriteString(fmt.Sprintf("%s %s%s %s\n", selected, graph, cp.label(item), item.String()))
 	}
 	return buffer.String()
 }
 
+func (cp *commitPanel) label(c commit) string {
+	if cp.revisions == nil {
+		return revisionLabel(c, nil)
+	}
+	return revisionLabel(c, *cp.revisions)
+}
+
 func (cp *commitPanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
 	handled, redraw = cp.ListPanel.Update(msg)
 	if len(cp.Items) > 0 && cp.Selected >= 0 && cp.Selected < len(cp.Items) {
@@ -45,7 +93,36 @@ func (cp *commitPanel) Update(msg tui.InputMessage) (handled bool, redraw bool)
 	return handled, redraw
 }
 
-func getCommits() ([]commit, error) {
+// setItems replaces the commits, keeping the selected one when it is still listed.
+func (cp *commitPanel) setItems(commits []commit) {
+	selected := 0
+	for i, c := range commits {
+		if cp.commit.Commit != nil && c.Hash == cp.commit.Hash {
+			selected = i
+		}
+	}
+	cp.Items = commits
+	cp.graph = graphColumns(commits)
+	cp.Selected = selected
+	if len(cp.Items) > 0 {
+		*cp.commit = cp.Items[cp.Selected]
+	}
+}
+
+// selectCommit selects c when it is listed, and reports whether it is.
+func (cp *commitPanel) selectCommit(c commit) bool {
+	for i, item := range cp.Items {
+		if c.Commit != nil && item.Hash == c.Hash {
+			cp.Selected = i
+			*cp.commit = item
+			return true
+		}
+	}
+	return false
+}
+
+// openRepo opens the git repository containing the current working directory.
+func openRepo() (*git.Repository, error) {
 	dir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current working directory: %w", err)
@@ -55,6 +132,32 @@ func getCommits() ([]commit, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open git repository at %s: %w", dir, err)
 	}
+	return repo, nil
+}
+
+// repoRoot returns the top level directory of the current git repository.
+func repoRoot() (string, error) {
+	repo, err := openRepo()
+	if err != nil {
+		return "", err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	return wt.Filesystem.Root(), nil
+}
+
+// getCommits returns the latest commits reachable from HEAD, or only the
+// first parent chain of HEAD when firstParent is set.
+func getCommits(firstParent bool) ([]commit, error) {
+	repo, err := openRepo()
+	if err != nil {
+		return nil, err
+	}
+	if firstParent {
+		return getFirstParentCommits(repo)
+	}
 
 	commitsIter, err := repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
 	if err != nil {
@@ -64,8 +167,8 @@ func getCommits() ([]commit, error) {
 	commits := []commit{}
 	count := 0
 	err = commitsIter.ForEach(func(c *object.Commit) error {
-		if count >= 20 {
-			return nil // Stop after 20 commits
+		if count >= maxCommits {
+			return nil // Stop after maxCommits commits
 		}
 		commits = append(commits, commit{c})
 		count++
@@ -77,6 +180,26 @@ func getCommits() ([]commit, error) {
 	return commits, nil
 }
 
+func getFirstParentCommits(repo *git.Repository) ([]commit, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	c, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	commits := []commit{{c}}
+	for len(commits) < maxCommits && c.NumParents() > 0 {
+		c, err = c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent of %s: %w", commits[len(commits)-1].Hash, err)
+		}
+		commits = append(commits, commit{c})
+	}
+	return commits, nil
+}
+
 func newCommitPanel(name string, commits []commit) commitPanel {
 	return commitPanel{
 		ListPanel: &tui.ListPanel[commit]{
@@ -87,5 +210,6 @@ func newCommitPanel(name string, commits []commit) commitPanel {
 			Items: commits,
 		},
 		commit: &commit{},
+		graph:  graphColumns(commits),
 	}
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// conduitResponse is the envelope of every Conduit API response.
+type conduitResponse struct {
+	Result       json.RawMessage `json:"response"`
+	ErrorCode    *string         `json:"error"`
+	ErrorMessage *string         `json:"errorMessage"`
+}
+
+// callConduit calls the Conduit method through arc, which handles the
+// authentication, and decodes the response into result.
+func callConduit(method string, params any, result any) error {
+	input, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s parameters: %w", method, err)
+	}
+	cmd := exec.Command("arc", "call-conduit", "--", method)
+	cmd.Stdin = bytes.NewReader(input)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to run 'arc call-conduit %s': %w", method, err)
+	}
+	return decodeConduit(method, output, result)
+}
+
+func decodeConduit(method string, output []byte, result any) error {
+	var response conduitResponse
+	if err := json.Unmarshal(output, &response); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if response.ErrorCode != nil {
+		message := ""
+		if response.ErrorMessage != nil {
+			message = *response.ErrorMessage
+		}
+		return fmt.Errorf("%s failed: %s: %s", method, *response.ErrorCode, message)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// searchCursor is the paging cursor of the *.search methods.
+type searchCursor struct {
+	After *string `json:"after"`
+}
+
+// getUsernames resolves user PHIDs to usernames. Unknown PHIDs are omitted.
+func getUsernames(phids []string) (map[string]string, error) {
+	names := map[string]string{}
+	if len(phids) == 0 {
+		return names, nil
+	}
+	var result struct {
+		Data []struct {
+			PHID   string `json:"phid"`
+			Fields struct {
+				Username string `json:"username"`
+			} `json:"fields"`
+		} `json:"data"`
+	}
+	params := map[string]any{"constraints": map[string]any{"phids": phids}}
+	if err := callConduit("user.search", params, &result); err != nil {
+		return nil, err
+	}
+	for _, user := range result.Data {
+		names[user.PHID] = user.Fields.Username
+	}
+	return names, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// configFile is the per repository configuration, at the root of the repository.
+const configFile = ".bow.json"
+
+type checkConfig struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// Kind is "lint" or "unit". It tells which arc step the check replaces.
+	Kind string `json:"kind"`
+}
+
+type config struct {
+	Checks []checkConfig `json:"checks"`
+	// Backend is the review backend, "phabricator" (the default) or "github".
+	Backend string       `json:"backend"`
+	GitHub  githubConfig `json:"github"`
+	// Scan configures the scan of the changes before their upload.
+	Scan scanConfig `json:"scan"`
+}
+
+type githubConfig struct {
+	// Repository is owner/name, read from the remote URL when empty.
+	Repository string `json:"repository"`
+	// Remote is the git remote the branches are pushed to, origin by default.
+	Remote string `json:"remote"`
+	// Base is the preferred base branch when several point at the base commit, main by default.
+	Base string `json:"base"`
+	// APIURL is the REST API root, https://api.github.com by default.
+	APIURL string `json:"api_url"`
+	// MergeMethod is merge, squash or rebase, merge by default.
+	MergeMethod string `json:"merge_method"`
+}
+
+// loadConfig reads the configuration of the current repository.
+// A missing file gives an empty configuration.
+func loadConfig() (config, error) {
+	var cfg config
+	root, err := repoRoot()
+	if err != nil {
+		return cfg, err
+	}
+	path := filepath.Join(root, configFile)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
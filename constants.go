@@ -8,6 +8,7 @@ const (
 	colorGreen  = "\033[32m"
 	colorYellow = "\033[33m"
 	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
 )
 
 func isDevMode() bool {
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"app/tui"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type trailer struct {
+	key   string
+	value string
+}
+
+// trailerRe matches a trailer line. Keys may contain spaces to accept
+// Phabricator's "Differential Revision" trailer.
+var trailerRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9 -]*): (.+)$`)
+
+// splitTrailers splits message into its body and its trailers, the last
+// paragraph when all its lines are trailers.
+func splitTrailers(message string) (string, []trailer) {
+	message = strings.TrimRight(message, "\n")
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) < 2 {
+		return message, nil
+	}
+	var trailers []trailer
+	for line := range strings.SplitSeq(paragraphs[len(paragraphs)-1], "\n") {
+		matches := trailerRe.FindStringSubmatch(line)
+		if matches == nil {
+			return message, nil
+		}
+		trailers = append(trailers, trailer{key: matches[1], value: strings.TrimSpace(matches[2])})
+	}
+	return strings.Join(paragraphs[:len(paragraphs)-1], "\n\n"), trailers
+}
+
+// commitDetails describes c: hashes, people, message, trailers and changed files.
+func commitDetails(c commit) []string {
+	const dateFormat = "2006-01-02 15:04:05 -0700"
+	lines := []string{
+		fmt.Sprintf("%scommit %s%s", colorYellow, c.Hash, colorReset),
+	}
+	var parents []string
+	for _, parent := range c.ParentHashes {
+		parents = append(parents, short(parent.String()))
+	}
+	if len(parents) > 0 {
+		lines = append(lines, "Parents:   "+strings.Join(parents, " "))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Author:    %s <%s> %s", c.Author.Name, c.Author.Email, c.Author.When.Format(dateFormat)),
+		fmt.Sprintf("Committer: %s <%s> %s", c.Committer.Name, c.Committer.Email, c.Committer.When.Format(dateFormat)),
+		"",
+	)
+
+	body, trailers := splitTrailers(c.Message)
+	for line := range strings.SplitSeq(body, "\n") {
+		lines = append(lines, "    "+line)
+	}
+	if len(trailers) > 0 {
+		lines = append(lines, "")
+		for _, t := range trailers {
+			lines = append(lines, fmt.Sprintf("    %s%s%s: %s", colorCyan, t.key, colorReset, t.value))
+		}
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		return append(lines, "", fmt.Sprintf("Failed to compute changed files: %v", err))
+	}
+	lines = append(lines, "")
+	var additions, deletions int
+	for _, stat := range stats {
+		additions += stat.Addition
+		deletions += stat.Deletion
+		lines = append(lines, fmt.Sprintf(" %s | %s+%d%s %s-%d%s", stat.Name, colorGreen, stat.Addition, colorReset, colorRed, stat.Deletion, colorReset))
+	}
+	lines = append(lines, fmt.Sprintf(" %d files changed, %d insertions(+), %d deletions(-)", len(stats), additions, deletions))
+	return lines
+}
+
+// detailPanel shows the details of the commit selected in the focused commit panel.
+type detailPanel struct {
+	*tui.InfoPanel
+	commit *commit
+	hash   string
+}
+
+func (dp *detailPanel) Draw(active bool) string {
+	if dp.commit == nil || dp.commit.Commit == nil {
+		return "No commit selected"
+	}
+	if dp.hash != dp.commit.Hash.String() {
+		dp.hash = dp.commit.Hash.String()
+		dp.Lines = commitDetails(*dp.commit)
+	}
+	return dp.InfoPanel.Draw(active)
+}
+
+func newDetailPanel(name string) detailPanel {
+	return detailPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
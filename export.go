@@ -0,0 +1,320 @@
+package main
+
+import (
+	"app/tui"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// rangeCommits returns the commits of the from..on range, each after its
+// parents. It fails with errNotAncestor when from is not an ancestor of on,
+// and when the range is longer than maxRangeWalk commits.
+func rangeCommits(from, on *object.Commit) ([]*object.Commit, error) {
+	walked, err := walkRange(from, on)
+	if err != nil {
+		return nil, err
+	}
+	inRange := map[plumbing.Hash]*object.Commit{}
+	for _, c := range walked {
+		inRange[c.Hash] = c
+	}
+	var commits []*object.Commit
+	added := map[plumbing.Hash]bool{}
+	var add func(c *object.Commit)
+	add = func(c *object.Commit) {
+		if added[c.Hash] {
+			return
+		}
+		added[c.Hash] = true
+		for _, parent := range c.ParentHashes {
+			if p, ok := inRange[parent]; ok {
+				add(p)
+			}
+		}
+		commits = append(commits, c)
+	}
+	for _, c := range walked {
+		add(c)
+	}
+	return commits, nil
+}
+
+// rangePatch returns the combined unified diff of the from..on range.
+func rangePatch(from, on *object.Commit) (*object.Patch, error) {
+	patch, err := from.Patch(on)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..%s: %w", short(from.Hash.String()), short(on.Hash.String()), err)
+	}
+	return patch, nil
+}
+
+// commitPatch returns the diff introduced by c against its first parent.
+func commitPatch(c *object.Commit) (*object.Patch, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of %s: %w", c.Hash, err)
+	}
+	parentTree := &object.Tree{}
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent of %s: %w", c.Hash, err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to get tree of %s: %w", parent.Hash, err)
+		}
+	}
+	patch, err := parentTree.Patch(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s: %w", c.Hash, err)
+	}
+	return patch, nil
+}
+
+// formatPatch formats c as the n-th of total patches, like git format-patch.
+func formatPatch(c *object.Commit, n, total int) (string, error) {
+	patch, err := commitPatch(c)
+	if err != nil {
+		return "", err
+	}
+	subject, body, _ := strings.Cut(strings.TrimRight(c.Message, "\n"), "\n")
+	body = strings.TrimLeft(body, "\n")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From %s Mon Sep 17 00:00:00 2001\n", c.Hash)
+	fmt.Fprintf(&b, "From: %s <%s>\n", c.Author.Name, c.Author.Email)
+	fmt.Fprintf(&b, "Date: %s\n", c.Author.When.Format("Mon, 2 Jan 2006 15:04:05 -0700"))
+	fmt.Fprintf(&b, "Subject: [PATCH %d/%d] %s\n\n", n, total, subject)
+	if body != "" {
+		b.WriteString(body + "\n")
+	}
+	b.WriteString("---\n")
+	b.WriteString(patch.Stats().String())
+	b.WriteString("\n")
+	b.WriteString(patch.String())
+	b.WriteString("-- \nbow\n\n")
+	return b.String(), nil
+}
+
+// patchFileName returns the file name git format-patch gives to the n-th patch.
+func patchFileName(c *object.Commit, n int) string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return fmt.Sprintf("%04d-%s.patch", n, slugify(subject, 52))
+}
+
+// slugify lowercases s and replaces the runs of other characters than
+// letters and digits with a dash, keeping at most maxLen bytes.
+func slugify(s string, maxLen int) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteRune('-')
+			dash = true
+		}
+	}
+	name := strings.TrimRight(slug.String(), "-")
+	if len(name) > maxLen {
+		name = strings.TrimRight(name[:maxLen], "-")
+	}
+	return name
+}
+
+// exportSeries writes one patch per commit of the range into dir, or all
+// of them to out when dir is empty, and returns the written files.
+func exportSeries(from, on *object.Commit, dir string, out io.Writer) ([]string, error) {
+	commits, err := rangeCommits(from, on)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, errors.New("empty range")
+	}
+	var files []string
+	for i, c := range commits {
+		text, err := formatPatch(c, i+1, len(commits))
+		if err != nil {
+			return nil, err
+		}
+		if dir == "" {
+			if _, err := io.WriteString(out, text); err != nil {
+				return nil, fmt.Errorf("failed to write patch: %w", err)
+			}
+			continue
+		}
+		path := filepath.Join(dir, patchFileName(c, i+1))
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write patch: %w", err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// exportCombined writes the combined diff of the range to path, or to out when path is empty.
+func exportCombined(from, on *object.Commit, path string, out io.Writer) error {
+	patch, err := rangePatch(from, on)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		if _, err := io.WriteString(out, patch.String()); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(patch.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	return nil
+}
+
+// resolveCommit resolves any git revision, like HEAD~2 or a short hash, to a commit.
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	c, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", rev, err)
+	}
+	return c, nil
+}
+
+// resolveRange resolves a from..on range of git revisions.
+func resolveRange(repo *git.Repository, spec string) (from, on *object.Commit, err error) {
+	fromRev, onRev, ok := strings.Cut(spec, "..")
+	if !ok || fromRev == "" {
+		return nil, nil, fmt.Errorf("invalid range %q, expected <from>..<on>", spec)
+	}
+	if onRev == "" {
+		onRev = "HEAD"
+	}
+	if from, err = resolveCommit(repo, fromRev); err != nil {
+		return nil, nil, err
+	}
+	if on, err = resolveCommit(repo, onRev); err != nil {
+		return nil, nil, err
+	}
+	return from, on, nil
+}
+
+// runExport implements the export subcommand and returns the exit code.
+func runExport(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	series := flags.Bool("series", false, "one patch per commit, like git format-patch, instead of a combined diff")
+	output := flags.String("o", "", "file, or directory with -series, to write to instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: bow export [-series] [-o path] <from>..<on>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	repo, err := openRepo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	from, on, err := resolveRange(repo, flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *series {
+		if *output != "" {
+			if err := os.MkdirAll(*output, 0755); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+		files, err := exportSeries(from, on, *output, out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, file := range files {
+			fmt.Fprintln(os.Stderr, file)
+		}
+		return 0
+	}
+	if err := exportCombined(from, on, *output, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// exportDir is where the ranges are exported by default, outside of the
+// working tree so that the patches are not taken for uncommitted changes.
+func exportDir() string {
+	return filepath.Join(cacheDir(), "exports")
+}
+
+// askExport asks where to export the selected range, then writes it there:
+// a patch series when the path is a directory, a combined diff otherwise.
+// Overwriting a file or the patches of a directory asks for confirmation.
+func (h *handler) askExport(app *tui.App) (redraw bool) {
+	if h.diffFromCommit.Commit == nil || h.diffOnCommit.Commit == nil {
+		return false
+	}
+	from, on := h.diffFromCommit.Commit, h.diffOnCommit.Commit
+	if err := os.MkdirAll(exportDir(), 0755); err != nil {
+		slog.Warn("failed to create the export directory", "error", err)
+	}
+	initial := filepath.Join(exportDir(), fmt.Sprintf("%s..%s.patch", short(from.Hash.String()), short(on.Hash.String())))
+	h.ask(app, "Export to (a directory for a series)", initial, func(path string) {
+		if path == "" {
+			return
+		}
+		info, statErr := os.Stat(path)
+		series := statErr == nil && info.IsDir()
+		export := func() {
+			var err error
+			if series {
+				_, err = exportSeries(from, on, path, nil)
+			} else {
+				err = exportCombined(from, on, path, nil)
+			}
+			if err != nil {
+				slog.Error("failed to export", "path", path, "error", err)
+				h.notify(colorRed + "Export failed: " + err.Error() + colorReset)
+				return
+			}
+			slog.Info("exported range", "from", from.Hash, "on", on.Hash, "path", path)
+			h.notify("Exported to " + path)
+		}
+		switch {
+		case series:
+			if patches, _ := filepath.Glob(filepath.Join(path, "[0-9][0-9][0-9][0-9]-*.patch")); len(patches) > 0 {
+				h.confirm(fmt.Sprintf("Overwrite the patches in %s?", path), export)
+				return
+			}
+		case statErr == nil:
+			h.confirm(fmt.Sprintf("Overwrite %s?", path), export)
+			return
+		}
+		export()
+	})
+	return true
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	githubName   = "github"
+	githubAPIURL = "https://api.github.com"
+)
+
+// githubBackend sends the revisions as GitHub pull requests, pushing the
+// commits to branches of the remote.
+type githubBackend struct {
+	apiURL      string
+	repository  string
+	remote      string
+	base        string
+	mergeMethod string
+	token       string
+	client      *http.Client
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubRef struct {
+	Ref string `json:"ref"`
+}
+
+type pullRequest struct {
+	Number  int        `json:"number"`
+	Title   string     `json:"title"`
+	Draft   bool       `json:"draft"`
+	HTMLURL string     `json:"html_url"`
+	User    githubUser `json:"user"`
+	Head    githubRef  `json:"head"`
+	Base    githubRef  `json:"base"`
+}
+
+type pullReview struct {
+	User  githubUser `json:"user"`
+	State string     `json:"state"`
+}
+
+func newGitHubBackend(cfg githubConfig) (*githubBackend, error) {
+	g := &githubBackend{
+		apiURL:      strings.TrimRight(cmp.Or(cfg.APIURL, githubAPIURL), "/"),
+		repository:  cfg.Repository,
+		remote:      cmp.Or(cfg.Remote, "origin"),
+		base:        cmp.Or(cfg.Base, "main"),
+		mergeMethod: cmp.Or(cfg.MergeMethod, "merge"),
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+	if g.repository == "" {
+		url, err := remoteURL(g.remote)
+		if err != nil {
+			return nil, err
+		}
+		if g.repository, err = repositoryFromURL(url); err != nil {
+			return nil, err
+		}
+	}
+	token, err := githubToken()
+	if err != nil && !isDevMode() {
+		return nil, err
+	}
+	g.token = token
+	return g, nil
+}
+
+// remoteURL returns the first URL of the git remote name.
+func remoteURL(name string) (string, error) {
+	repo, err := openRepo()
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get remote %s: %w", name, err)
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote %s has no URL", name)
+	}
+	return urls[0], nil
+}
+
+var repositoryRe = regexp.MustCompile(`[:/]([^/:]+/[^/]+?)(?:\.git)?/?$`)
+
+// repositoryFromURL returns the owner/name of a GitHub remote URL, over SSH or HTTPS.
+func repositoryFromURL(url string) (string, error) {
+	matches := repositoryRe.FindStringSubmatch(url)
+	if matches == nil {
+		return "", fmt.Errorf("failed to read the GitHub repository from %q, set github.repository in %s", url, configFile)
+	}
+	return matches[1], nil
+}
+
+// githubToken returns $GITHUB_TOKEN, or the token of the GitHub CLI.
+func githubToken() (string, error) {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token, nil
+	}
+	output, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return "", errors.New("no GitHub token: set GITHUB_TOKEN or log in with 'gh auth login'")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// request calls the REST API, encoding body and decoding the response into out when not nil.
+func (g *githubBackend) request(method, path string, body, out any) error {
+	_, err := g.send(method, g.apiURL+path, body, out)
+	return err
+}
+
+var nextLinkRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// githubList gets every page of the list at path, following the next links
+// of the responses.
+func githubList[T any](g *githubBackend, path string) ([]T, error) {
+	var items []T
+	for url := g.apiURL + path; url != ""; {
+		var page []T
+		header, err := g.send(http.MethodGet, url, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		url = ""
+		if matches := nextLinkRe.FindStringSubmatch(header.Get("Link")); matches != nil {
+			url = matches[1]
+		}
+	}
+	return items, nil
+}
+
+// send calls the API at url, like request, and returns the headers of the response.
+func (g *githubBackend) send(method, url string, body, out any) (http.Header, error) {
+	path := strings.TrimPrefix(url, g.apiURL)
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode GitHub request: %w", err)
+		}
+		reader = bytes.NewReader(content)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call GitHub %s %s: %w", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(content, &apiErr)
+		return nil, fmt.Errorf("GitHub %s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+	}
+	if out != nil {
+		if err := json.Unmarshal(content, out); err != nil {
+			return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+func (g *githubBackend) repoPath(path string) string {
+	return "/repos/" + g.repository + path
+}
+
+// pullNumber parses a pull request id, #12 or 12.
+func pullNumber(id string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "#"))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid pull request %q", id)
+	}
+	return n, nil
+}
+
+func (g *githubBackend) Name() string {
+	return githubName
+}
+
+func (g *githubBackend) List() ([]diff, error) {
+	if isDevMode() {
+		return mockDiffs(), nil
+	}
+	var user githubUser
+	if err := g.request(http.MethodGet, "/user", nil, &user); err != nil {
+		return nil, err
+	}
+	pulls, err := githubList[pullRequest](g, g.repoPath("/pulls?state=open&per_page=100"))
+	if err != nil {
+		return nil, err
+	}
+	var diffs []diff
+	for _, pr := range pulls {
+		if pr.User.Login != user.Login {
+			continue
+		}
+		s, err := g.status(pr)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff{status: s, id: fmt.Sprintf("#%d", pr.Number), message: pr.Title})
+	}
+	return diffs, nil
+}
+
+// status maps the draft state and the latest review of each reviewer to a
+// Phabricator status.
+func (g *githubBackend) status(pr pullRequest) (status, error) {
+	if pr.Draft {
+		return Draft, nil
+	}
+	reviews, err := githubList[pullReview](g, g.repoPath(fmt.Sprintf("/pulls/%d/reviews?per_page=100", pr.Number)))
+	if err != nil {
+		return 0, err
+	}
+	latest := map[string]string{}
+	for _, review := range reviews {
+		if review.State == "APPROVED" || review.State == "CHANGES_REQUESTED" {
+			latest[review.User.Login] = review.State
+		}
+	}
+	s := NeedsReview
+	for _, state := range latest {
+		if state == "CHANGES_REQUESTED" {
+			return NeedsRevision, nil
+		}
+		s = Accepted
+	}
+	return s, nil
+}
+
+// push pushes hash to branch of the remote, writing the git output to out.
+func (g *githubBackend) push(hash, branch string, force bool, out *bytes.Buffer) error {
+	args := []string{"push"}
+	if force {
+		args = append(args, "--force")
+	}
+	args = append(args, g.remote, hash+":refs/heads/"+branch)
+	output, err := exec.Command("git", args...).CombinedOutput()
+	out.Write(output)
+	if err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", short(hash), branch, err)
+	}
+	return nil
+}
+
+// remoteBranches returns the hash of each branch of the remote.
+func (g *githubBackend) remoteBranches() (map[string]string, error) {
+	output, err := exec.Command("git", "ls-remote", "--heads", g.remote).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the branches of %s: %w", g.remote, err)
+	}
+	branches := map[string]string{}
+	for line := range strings.SplitSeq(strings.TrimSpace(string(output)), "\n") {
+		hash, ref, ok := strings.Cut(line, "\t")
+		if ok {
+			branches[strings.TrimPrefix(ref, "refs/heads/")] = hash
+		}
+	}
+	return branches, nil
+}
+
+// freeBranch returns name, or name with the first free numeric suffix when
+// the remote already has a branch name, so that creating a pull request
+// never moves the branch of another one.
+func (g *githubBackend) freeBranch(name string) (string, error) {
+	branches, err := g.remoteBranches()
+	if err != nil {
+		return "", err
+	}
+	branch := name
+	for i := 2; branches[branch] != ""; i++ {
+		branch = fmt.Sprintf("%s-%d", name, i)
+	}
+	return branch, nil
+}
+
+// baseFor returns a branch of the remote pointing at from, preferring the
+// configured base. Without one, from is pushed to a bow/base-<hash> branch.
+func (g *githubBackend) baseFor(from string, out *bytes.Buffer) (string, error) {
+	heads, err := g.remoteBranches()
+	if err != nil {
+		return "", err
+	}
+	var branches []string
+	for branch, hash := range heads {
+		if hash == from {
+			branches = append(branches, branch)
+		}
+	}
+	if slices.Contains(branches, g.base) {
+		return g.base, nil
+	}
+	if len(branches) > 0 {
+		slices.Sort(branches)
+		return branches[0], nil
+	}
+	branch := "bow/base-" + short(from)
+	return branch, g.push(from, branch, true, out)
+}
+
+func (g *githubBackend) Create(from, on, message string, flags []string) ([]byte, error) {
+	title, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("missing title")
+	}
+	head := "bow/" + slugify(title, 40)
+	if isDevMode() {
+		return []byte(fmt.Sprintf("Would push %s to %s and open a pull request %q\n", short(on), head, title)), nil
+	}
+
+	var output bytes.Buffer
+	base, err := g.baseFor(from, &output)
+	if err != nil {
+		return output.Bytes(), err
+	}
+	if head, err = g.freeBranch(head); err != nil {
+		return output.Bytes(), err
+	}
+	if err := g.push(on, head, false, &output); err != nil {
+		return output.Bytes(), err
+	}
+	_, draft := hasFlag(flags, "--draft")
+	var pr pullRequest
+	err = g.request(http.MethodPost, g.repoPath("/pulls"), map[string]any{
+		"title": title,
+		"body":  strings.TrimSpace(body),
+		"head":  head,
+		"base":  base,
+		"draft": draft,
+	}, &pr)
+	if err != nil {
+		return output.Bytes(), err
+	}
+	if err := g.requestReviewers(pr.Number, flags); err != nil {
+		return output.Bytes(), err
+	}
+	fmt.Fprintf(&output, "Created pull request #%d: %s\n", pr.Number, pr.HTMLURL)
+	return output.Bytes(), nil
+}
+
+// requestReviewers requests the reviews of the --reviewers of flags, if any.
+func (g *githubBackend) requestReviewers(n int, flags []string) error {
+	reviewers, _ := hasFlag(flags, "--reviewers")
+	if reviewers == "" {
+		return nil
+	}
+	return g.request(http.MethodPost, g.repoPath(fmt.Sprintf("/pulls/%d/requested_reviewers", n)), map[string]any{
+		"reviewers": strings.Split(reviewers, ","),
+	}, nil)
+}
+
+// Update force pushes on to the head branch of the pull request, moves its
+// base when from changed, requests the reviews of --reviewers and comments
+// with message. Like arc, --draft only applies to new pull requests.
+func (g *githubBackend) Update(id, from, on, message string, flags []string) ([]byte, error) {
+	n, err := pullNumber(id)
+	if err != nil {
+		return nil, err
+	}
+	if isDevMode() {
+		return []byte(fmt.Sprintf("Would push %s to the branch of pull request #%d\n", short(on), n)), nil
+	}
+
+	var output bytes.Buffer
+	var pr pullRequest
+	if err := g.request(http.MethodGet, g.repoPath(fmt.Sprintf("/pulls/%d", n)), nil, &pr); err != nil {
+		return nil, err
+	}
+	base, err := g.baseFor(from, &output)
+	if err != nil {
+		return output.Bytes(), err
+	}
+	if err := g.push(on, pr.Head.Ref, true, &output); err != nil {
+		return output.Bytes(), err
+	}
+	if base != pr.Base.Ref {
+		if err := g.request(http.MethodPatch, g.repoPath(fmt.Sprintf("/pulls/%d", n)), map[string]any{"base": base}, nil); err != nil {
+			return output.Bytes(), err
+		}
+	}
+	if err := g.requestReviewers(n, flags); err != nil {
+		return output.Bytes(), err
+	}
+	if strings.TrimSpace(message) != "" {
+		if err := g.request(http.MethodPost, g.repoPath(fmt.Sprintf("/issues/%d/comments", n)), map[string]any{"body": message}, nil); err != nil {
+			return output.Bytes(), err
+		}
+	}
+	if _, draft := hasFlag(flags, "--draft"); draft && !pr.Draft {
+		fmt.Fprintf(&output, "Left pull request #%d ready for review, --draft only applies to new ones\n", n)
+	}
+	fmt.Fprintf(&output, "Updated pull request #%d: %s\n", n, pr.HTMLURL)
+	return output.Bytes(), nil
+}
+
+func (g *githubBackend) Land(id string) ([]byte, error) {
+	n, err := pullNumber(id)
+	if err != nil {
+		return nil, err
+	}
+	if isDevMode() {
+		return []byte(fmt.Sprintf("Would merge pull request #%d\n", n)), nil
+	}
+	var result struct {
+		SHA string `json:"sha"`
+	}
+	err = g.request(http.MethodPut, g.repoPath(fmt.Sprintf("/pulls/%d/merge", n)), map[string]any{"merge_method": g.mergeMethod}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("Merged pull request #%d as %s\n", n, short(result.SHA))), nil
+}
@@ -2,7 +2,10 @@ module app
 
 go 1.25.1
 
-require github.com/go-git/go-git/v6 v6.0.0-20250910120214-3a68d0404116
+require (
+	github.com/go-git/go-git/v6 v6.0.0-20250910120214-3a68d0404116
+	github.com/sergi/go-diff v1.4.0
+)
 
 require (
 	dario.cat/mergo v1.0.1 // indirect
@@ -17,7 +20,6 @@ require (
 	github.com/kevinburke/ssh_config v1.4.0 // indirect
 	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
 	github.com/pjbgf/sha1cd v0.5.0 // indirect
-	github.com/sergi/go-diff v1.4.0 // indirect
 	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/exp v0.0.0-20250531010427-b6e5de432a8b // indirect
 	golang.org/x/net v0.43.0 // indirect
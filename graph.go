@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+const (
+	graphCommit = "●"
+	graphLane   = "│"
+	graphMerge  = "╮"
+	graphJoinR  = "╯"
+	graphJoinL  = "╰"
+)
+
+// graphColumns computes for each commit, in the given order, a graph column
+// like git log --graph on a single row. Each lane follows the next expected
+// commit of a branch: lanes waiting for the same commit join on its row, and
+// the extra parents of a merge open new lanes.
+func graphColumns(commits []commit) []string {
+	var lanes []plumbing.Hash
+	rows := make([][]string, len(commits))
+	for i, c := range commits {
+		col := slices.Index(lanes, c.Hash)
+		if col < 0 {
+			col = slices.Index(lanes, plumbing.ZeroHash)
+			if col < 0 {
+				col = len(lanes)
+				lanes = append(lanes, plumbing.ZeroHash)
+			}
+		}
+
+		row := make([]string, len(lanes))
+		for j, lane := range lanes {
+			switch {
+			case j == col:
+				row[j] = graphCommit
+			case lane == c.Hash && j > col:
+				row[j] = graphJoinR
+				lanes[j] = plumbing.ZeroHash
+			case lane == c.Hash:
+				row[j] = graphJoinL
+				lanes[j] = plumbing.ZeroHash
+			case lane != plumbing.ZeroHash:
+				row[j] = graphLane
+			default:
+				row[j] = " "
+			}
+		}
+
+		lanes[col] = plumbing.ZeroHash
+		if len(c.ParentHashes) > 0 {
+			lanes[col] = c.ParentHashes[0]
+		}
+		for _, parent := range c.ParentHashes[min(1, len(c.ParentHashes)):] {
+			if slices.Contains(lanes, parent) {
+				continue
+			}
+			free := slices.Index(lanes, plumbing.ZeroHash)
+			if free < 0 {
+				free = len(lanes)
+				lanes = append(lanes, plumbing.ZeroHash)
+				row = append(row, " ")
+			}
+			lanes[free] = parent
+			if free > col {
+				row[free] = graphMerge
+			}
+		}
+		rows[i] = row
+	}
+
+	width := 0
+	for _, row := range rows {
+		for len(row) > 0 && row[len(row)-1] == " " {
+			row = row[:len(row)-1]
+		}
+		width = max(width, len(row))
+	}
+	columns := make([]string, len(rows))
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, " ")
+		}
+		columns[i] = strings.Join(row[:width], " ")
+	}
+	return columns
+}
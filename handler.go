@@ -2,15 +2,19 @@ package main
 
 import (
 	"app/tui"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type handler struct {
 	*tui.DefaultGlobalHandler
 	panels         panels
 	activeCommand  command
+	leftPanel      *tui.Layout
 	rightPanel     *tui.Layout
 	diffFromCommit *commit
 	diffOnCommit   *commit
@@ -18,58 +22,869 @@ type handler struct {
 	updateMsg      *string
 	createMsg      *string
 	lastOutput     string
+	focused        string
+	config         config
+	backend        ReviewBackend
+	checks         *checkRun
+	checking       bool
+	showChecks     bool
+	scan           *scanRun
+	showScan       bool
+	showOptions    bool
+	showComments   bool
+	showStack      bool
+	showInterdiff  bool
+	refreshing     atomic.Bool
+	pending        *confirmation
+	firstParent    bool
+	showDetail     bool
+	rangeMode      bool
+	rangeCount     rangeCount
+	showLogs       bool
+	showPrompt     bool
+	showWorktree   bool
+	showQueue      bool
+	worktreeOK     bool
+	stashed        bool
+	notice         string
+	noticeAt       time.Time
+	// prefilled is the message last generated for each command, replaced
+	// by the next one as long as the user did not edit it.
+	prefilled map[command]string
+	// uploaded caches the commits of the latest diff of each revision,
+	// loaded in the background for the ones in loadingUploaded.
+	uploaded        map[string][]uploadedCommit
+	loadingUploaded map[string]bool
+	// loadingInterdiff are the revisions whose latest diff the interdiff
+	// panel is loading, loadingComments and loadingStack the ones whose
+	// comments and stack the comments and stack panels are loading.
+	loadingInterdiff map[string]bool
+	loadingComments  map[string]bool
+	loadingStack     map[string]bool
+	// editing are the revisions whose lifecycle action is running.
+	editing map[string]bool
+	// launch is what bow was launched on, until it is loaded and selected.
+	launch *launchTarget
+	app    *tui.App
+}
+
+// confirmation is an action waiting for the user to press y.
+type confirmation struct {
+	prompt string
+	action func()
+}
+
+// notify shows text in the status bar for a few seconds.
+func (h *handler) notify(text string) {
+	h.notice = text
+	h.noticeAt = time.Now()
+}
+
+// ask shows the prompt panel with title, focuses it, and calls onSubmit
+// with the submitted text once the prompt is closed and the previously
+// focused panel focused again.
+func (h *handler) ask(app *tui.App, title, initial string, onSubmit func(text string)) {
+	previous := h.focused
+	h.panels.prompt.ask(title, initial, func(text string) {
+		h.showPrompt = false
+		h.layoutRight()
+		h.refocus(app, previous)
+		onSubmit(text)
+	})
+	h.showPrompt = true
+	h.layoutRight()
+	app.Relayout()
+	app.FocusPanel(title)
+}
+
+// refocus positions the panels of the changed layout, and focuses the
+// panel named previous again, or the first one when it is gone.
+func (h *handler) refocus(app *tui.App, previous string) {
+	app.Relayout()
+	if !app.FocusPanel(previous) {
+		app.FocusPanel("0")
+	}
+}
+
+// confirm asks the user to confirm with y before running action.
+func (h *handler) confirm(prompt string, action func()) {
+	h.pending = &confirmation{prompt: prompt, action: action}
 }
 
 func (h *handler) GetStatus() string {
-	return "HEY"
+	if h.pending != nil {
+		return fmt.Sprintf(" %s [y/N] ", h.pending.prompt)
+	}
+	segments := []string{string(h.activeCommand)}
+	if h.notice != "" && time.Since(h.noticeAt) < highlightDuration {
+		segments = append([]string{h.notice}, segments...)
+	}
+	if h.activeCommand == Inbox {
+		segments = append(segments, h.target())
+	} else {
+		rangeText, rangeErr := h.rangeStatus()
+		segments = append(segments, rangeText, h.target())
+		if warnings := h.warnings(rangeErr); len(warnings) > 0 {
+			segments = append(segments, colorRed+"⚠ "+strings.Join(warnings, ", ")+colorReset)
+		}
+		segments = append(segments, "arc: "+h.panels.options.options.String())
+	}
+	if keys, ok := panelKeys[h.focused]; ok {
+		segments = append(segments, keys)
+	}
+	segments = append(segments, globalKeys)
+
+	width := 80
+	if h.app != nil {
+		width, _ = h.app.Size()
+	}
+	return statusLine(segments, width)
 }
 
-func (h *handler) OnPanelSwitch(app *tui.App, panelName string) {}
+func (h *handler) OnPanelSwitch(app *tui.App, panelName string) {
+	h.focused = panelName
+	switch panelName {
+	case h.panels.diffFrom.Title:
+		h.panels.detail.commit = h.diffFromCommit
+	case h.panels.diffOn.Title:
+		h.panels.detail.commit = h.diffOnCommit
+	case h.panels.rangeSel.Title:
+		h.panels.detail.commit = h.panels.rangeSel.cursor
+	case h.panels.updateMsg.Title:
+		h.prefillMessage(false)
+	}
+}
 
 func (h *handler) UpdateGlobal(app *tui.App, msg tui.InputMessage) (redraw bool) {
+	if h.pending != nil {
+		pending := h.pending
+		h.pending = nil
+		if msg.IsChar('y') || msg.IsChar('Y') {
+			pending.action()
+		}
+		return true
+	}
+	// The panels taking text handle the letters before, so that the
+	// actions never fire while typing
+	if action, ok := lifecycleActionOf(msg); ok && h.activeCommand == Update {
+		return h.editLifecycle(app, action)
+	}
+
 	switch {
+	case h.focused == h.panels.inbox.Title && msg.IsChar('a'):
+		return h.reviewAction(app, "accept")
+	case h.focused == h.panels.inbox.Title && msg.IsChar('x'):
+		return h.reviewAction(app, "reject")
+	case h.focused == h.panels.inbox.Title && msg.IsChar('m'):
+		return h.reviewAction(app, "comment")
+	case msg.HasModifier(tui.ModCtrl) && msg.IsChar('e'):
+		if h.focused == h.panels.updateMsg.Title {
+			return h.editMessage(app)
+		}
+	case msg.HasModifier(tui.ModCtrl) && msg.IsChar('g'):
+		if h.focused == h.panels.updateMsg.Title {
+			return h.prefillMessage(true)
+		}
+	case msg.HasModifier(tui.ModCtrl) && msg.IsChar('s'):
+		return h.submit(app)
+	case msg.HasModifier(tui.ModCtrl) && msg.IsChar('l'):
+		// Not from the message, which would take the y of the confirmation
+		if h.activeCommand == Update && h.focused == h.panels.diffs.Title {
+			return h.land(app)
+		}
 	case msg.IsChar('u'):
-		if h.activeCommand != Update {
+		if h.activeCommand != Update || h.showChecks || h.showScan {
 			h.activeCommand = Update
-			*h.rightPanel = &tui.VerticalSplit{
-				Panels: []tui.Layout{
-					&tui.PanelNode{Panel: &h.panels.diffs, Weight: 1},
-					&tui.PanelNode{Panel: &h.panels.updateMsg, Weight: 1},
-				},
-				Weight: 1,
-			}
+			h.showChecks = false
+			h.showScan = false
+			h.layoutRight()
 			return true
 		}
 	case msg.IsChar('c'):
-		if h.activeCommand != Create {
+		if h.activeCommand != Create || h.showChecks || h.showScan {
 			h.activeCommand = Create
-			*h.rightPanel = &tui.PanelNode{Panel: &h.panels.createMsg}
+			h.showChecks = false
+			h.showScan = false
+			h.layoutRight()
 			return true
 		}
-	case msg.HasModifier(tui.ModCtrl) && msg.IsChar('s'):
-		output, err := h.runUpdate()
-		if err != nil {
-			slog.Error("failed to run update", "error", err, "output", string(output))
+	case msg.IsChar('I'):
+		if !h.phabricatorOnly("the inbox") {
+			return true
+		}
+		if h.activeCommand != Inbox {
+			h.activeCommand = Inbox
+			h.showChecks = false
+			h.showScan = false
+			h.loadInbox(app)
+			h.layoutRight()
+			return true
+		}
+	case msg.IsChar('o'):
+		h.showOptions = !h.showOptions
+		h.layoutRight()
+		return true
+	case msg.IsChar('e'):
+		if h.activeCommand != Inbox {
+			return h.askExport(app)
+		}
+	case msg.IsChar('l'):
+		h.showLogs = !h.showLogs
+		h.layoutRight()
+		return true
+	case msg.IsChar('d'):
+		h.showDetail = !h.showDetail
+		h.layoutLeft()
+		return true
+	case msg.IsChar('L'):
+		h.rangeMode = !h.rangeMode
+		if h.rangeMode {
+			h.panels.rangeSel.selectRange()
+		} else {
+			h.panels.diffFrom.selectCommit(*h.diffFromCommit)
+			h.panels.diffOn.selectCommit(*h.diffOnCommit)
+		}
+		h.layoutLeft()
+		return true
+	case msg.IsChar('g'):
+		// The mode changes with the reload of the commits, not while
+		// another one is running
+		if !h.reload(app, !h.firstParent) {
+			h.notify(colorYellow + "A reload is running, press g again once it is done" + colorReset)
+			return true
+		}
+		h.firstParent = !h.firstParent
+		return true
+	case msg.IsChar('R'):
+		h.refresh(app)
+	case msg.IsChar('i'):
+		if h.activeCommand == Update {
+			if !h.phabricatorOnly("comments") {
+				return true
+			}
+			return h.toggleComments()
+		}
+	case msg.IsChar('s'):
+		if h.activeCommand == Update {
+			if !h.phabricatorOnly("the stack") {
+				return true
+			}
+			return h.toggleStack()
+		}
+	case msg.IsChar('p'):
+		if h.activeCommand == Update {
+			if !h.phabricatorOnly("the interdiff") {
+				return true
+			}
+			return h.toggleInterdiff()
+		}
+	case msg.IsChar('r'):
+		if h.activeCommand == Update {
+			return h.restack(app)
+		}
+	case msg.IsChar('!'):
+		if h.showScan && h.scan != nil {
+			for _, f := range h.scan.findings {
+				slog.Warn("submitting despite a scan finding", "path", f.path, "line", f.line, "rule", f.rule)
+			}
+			h.scan.overridden = true
+			h.showScan = false
+			return h.submit(app)
+		}
+		if h.checks != nil && !h.checks.passed() && h.diffOnCommit.Commit != nil && h.checks.hash == h.diffOnCommit.Hash.String() {
+			slog.Warn("submitting despite failed checks", "commit", h.checks.hash)
+			h.checks.overridden = true
+			return h.submit(app)
 		}
-		h.lastOutput = string(output)
-		app.Stop()
 	default:
 		return h.DefaultGlobalHandler.UpdateGlobal(app, msg)
 	}
 	return false
 }
 
-func (h *handler) runUpdate() ([]byte, error) {
-	if isDevMode() {
-		output := fmt.Sprintf("Would run: arc diff %s --head %s --update %s --message %s\n", h.diffFromCommit.Hash.String(), h.diffOnCommit.Hash.String(), h.diffToUpdate.id, *h.updateMsg)
-		return []byte(output), nil
+// layoutLeft rebuilds the left side of the layout from the handler state.
+func (h *handler) layoutLeft() {
+	left := &tui.VerticalSplit{
+		Panels: []tui.Layout{
+			&tui.PanelNode{Panel: &h.panels.diffFrom, Weight: 1},
+			&tui.PanelNode{Panel: &h.panels.diffOn, Weight: 1},
+		},
+		Weight: 2,
+	}
+	if h.rangeMode {
+		left.Panels = []tui.Layout{&tui.PanelNode{Panel: &h.panels.rangeSel, Weight: 2}}
+	}
+	if h.showDetail {
+		left.Panels = append(left.Panels, &tui.PanelNode{Panel: &h.panels.detail, Weight: 1})
+	}
+	*h.leftPanel = left
+}
+
+// layoutRight rebuilds the right side of the layout from the handler state.
+func (h *handler) layoutRight() {
+	var right tui.Layout
+	switch {
+	case h.showPrompt:
+		right = &tui.PanelNode{Panel: &h.panels.prompt}
+	case h.showWorktree:
+		right = &tui.PanelNode{Panel: &h.panels.worktree}
+	case h.showQueue:
+		right = &tui.PanelNode{Panel: &h.panels.queue}
+	case h.showScan:
+		right = &tui.PanelNode{Panel: &h.panels.scan}
+	case h.showChecks:
+		right = &tui.PanelNode{Panel: &h.panels.checks}
+	case h.activeCommand == Inbox:
+		right = &tui.PanelNode{Panel: &h.panels.inbox}
+	case h.activeCommand == Create:
+		right = &tui.PanelNode{Panel: &h.panels.createMsg}
+	default:
+		split := &tui.VerticalSplit{
+			Panels: []tui.Layout{
+				&tui.PanelNode{Panel: &h.panels.diffs, Weight: 1},
+				&tui.PanelNode{Panel: &h.panels.updateMsg, Weight: 1},
+			},
+			Weight: 1,
+		}
+		if h.showComments {
+			split.Panels = append(split.Panels, &tui.PanelNode{Panel: &h.panels.comments, Weight: 2})
+		}
+		if h.showStack {
+			split.Panels = append(split.Panels, &tui.PanelNode{Panel: &h.panels.stack, Weight: 2})
+		}
+		if h.showInterdiff {
+			split.Panels = append(split.Panels, &tui.PanelNode{Panel: &h.panels.interdiff, Weight: 2})
+		}
+		right = split
+	}
+	if h.showOptions {
+		right = &tui.VerticalSplit{
+			Panels: []tui.Layout{
+				right,
+				&tui.PanelNode{Panel: &h.panels.options, Weight: 1},
+			},
+			Weight: 1,
+		}
+	}
+	if h.showLogs {
+		right = &tui.VerticalSplit{
+			Panels: []tui.Layout{
+				right,
+				&tui.PanelNode{Panel: &h.panels.logs, Weight: 1},
+			},
+			Weight: 1,
+		}
+	}
+	*h.rightPanel = right
+}
+
+// toggleComments shows or hides the review comments of the revision to update.
+func (h *handler) toggleComments() (redraw bool) {
+	h.showComments = !h.showComments
+	if h.showComments {
+		// Comments may have been added since
+		h.panels.comments.forget()
+	}
+	h.layoutRight()
+	return true
+}
+
+// loadComments fetches the comments of the revision id in the background
+// for the comments panel.
+func (h *handler) loadComments(id string) {
+	if h.loadingComments[id] {
+		return
+	}
+	if h.loadingComments == nil {
+		h.loadingComments = map[string]bool{}
+	}
+	h.loadingComments[id] = true
+	app := h.app
+	go func() {
+		comments, err := getComments(id)
+		app.Post(func() {
+			delete(h.loadingComments, id)
+			panel := &h.panels.comments
+			if panel.loaded == nil {
+				panel.loaded = map[string]loadedComments{}
+			}
+			panel.loaded[id] = loadedComments{comments: comments, err: err}
+			panel.shown = ""
+		})
+	}()
+}
+
+// toggleStack shows or hides the stack of the revision to update.
+func (h *handler) toggleStack() (redraw bool) {
+	h.showStack = !h.showStack
+	if h.showStack {
+		// Revisions may have been stacked since
+		h.panels.stack.forget()
+	}
+	h.layoutRight()
+	return true
+}
+
+// loadStack fetches the stack of the revision id in the background for the
+// stack panel.
+func (h *handler) loadStack(id string) {
+	if h.loadingStack[id] {
+		return
+	}
+	if h.loadingStack == nil {
+		h.loadingStack = map[string]bool{}
+	}
+	h.loadingStack[id] = true
+	app := h.app
+	go func() {
+		nodes, edges, err := getStack(id)
+		app.Post(func() {
+			delete(h.loadingStack, id)
+			panel := &h.panels.stack
+			if panel.loaded == nil {
+				panel.loaded = map[string]loadedStack{}
+			}
+			panel.loaded[id] = loadedStack{nodes: nodes, edges: edges, err: err}
+			panel.shown = ""
+		})
+	}()
+}
+
+// toggleInterdiff shows or hides what the range changes compared to the
+// latest diff of the revision to update.
+func (h *handler) toggleInterdiff() (redraw bool) {
+	h.showInterdiff = !h.showInterdiff
+	if h.showInterdiff {
+		// The revision may have been updated since
+		h.panels.interdiff.forget()
+	}
+	h.layoutRight()
+	return true
+}
+
+// loadInterdiff fetches the latest diff of the revision id in the
+// background for the interdiff panel.
+func (h *handler) loadInterdiff(id string) {
+	if h.loadingInterdiff[id] {
+		return
+	}
+	if h.loadingInterdiff == nil {
+		h.loadingInterdiff = map[string]bool{}
+	}
+	h.loadingInterdiff[id] = true
+	app := h.app
+	go func() {
+		patch, err := latestRawDiff(id)
+		app.Post(func() {
+			delete(h.loadingInterdiff, id)
+			panel := &h.panels.interdiff
+			if panel.uploaded == nil {
+				panel.uploaded = map[string]rawDiff{}
+			}
+			panel.uploaded[id] = rawDiff{patch: patch, err: err}
+			panel.shown = ""
+		})
+	}()
+}
+
+// startChecks runs the configured checks against hash in the background
+// and shows them running. Their results are shown once they are done.
+func (h *handler) startChecks(app *tui.App, hash string) {
+	h.showChecks = true
+	h.layoutRight()
+	if h.checking {
+		h.notify(colorYellow + "The checks are still running" + colorReset)
+		return
+	}
+	h.checking = true
+	checks := h.config.Checks
+	h.panels.checks.setRunning(checks, hash)
+	go func() {
+		run, err := runChecks(checks, hash)
+		app.Post(func() {
+			h.checking = false
+			if err != nil {
+				slog.Error("failed to run checks", "error", err)
+				h.panels.checks.Lines = []string{colorRed + "Failed to run the checks: " + err.Error() + colorReset}
+				return
+			}
+			h.checks = run
+			h.panels.checks.setRun(run)
+		})
+	}()
+}
+
+// submit runs the configured checks against the diffOn commit and scans the
+// changes of the range, then runs the active command once they passed or
+// were overridden.
+func (h *handler) submit(app *tui.App) (redraw bool) {
+	if h.activeCommand == Inbox {
+		return false
+	}
+	if h.diffFromCommit.Commit == nil || h.diffOnCommit.Commit == nil {
+		h.notify(colorYellow + "Nothing to submit: " + errNoRange.Error() + colorReset)
+		return true
+	}
+	hash := h.diffOnCommit.Hash.String()
+	if len(h.config.Checks) > 0 {
+		if h.checks == nil || h.checks.hash != hash {
+			h.startChecks(app, hash)
+			return true
+		}
+		if !h.checks.passed() && !h.checks.overridden {
+			h.showChecks = true
+			h.layoutRight()
+			return true
+		}
+	}
+	from := h.diffFromCommit.Hash.String()
+	if h.scan == nil || h.scan.from != from || h.scan.on != hash {
+		run, err := scanRange(*h.diffFromCommit, *h.diffOnCommit, h.config.Scan)
+		if err != nil {
+			slog.Error("failed to scan the changes", "error", err)
+			h.notify(colorRed + "Failed to scan the changes: " + err.Error() + colorReset)
+			return true
+		}
+		h.scan = run
+	}
+	if len(h.scan.findings) > 0 && !h.scan.overridden {
+		slog.Warn("scan blocked the submission", "findings", len(h.scan.findings))
+		h.panels.scan.setRun(h.scan)
+		h.showScan = true
+		h.showChecks = false
+		h.layoutRight()
+		app.Relayout()
+		app.FocusPanel(h.panels.scan.Title)
+		return true
+	}
+	if !h.worktreeOK {
+		files, err := dirtyFiles()
+		if err != nil {
+			slog.Error("failed to inspect the working tree", "error", err)
+			return false
+		}
+		if len(files) > 0 {
+			previous := h.focused
+			h.panels.worktree.ask(files, func(choice worktreeChoice) { h.handleWorktree(app, previous, choice) })
+			h.showWorktree = true
+			h.layoutRight()
+			app.Relayout()
+			app.FocusPanel(h.panels.worktree.Title)
+			return true
+		}
+	}
+
+	op, err := h.operation()
+	if err != nil {
+		slog.Error("failed to describe the operation", "error", err)
+		h.notify(colorRed + "Nothing to submit: " + err.Error() + colorReset)
+		return true
+	}
+	output, err := op.run(h.backend)
+	if err != nil {
+		slog.Error("failed to run command", "command", op.Command, "error", err, "output", string(output))
+	}
+	if err := recordOperation(op); err != nil {
+		slog.Error("failed to record operation", "error", err)
+	}
+	h.lastOutput = string(output)
+	h.worktreeOK = false
+	if h.stashed {
+		if err := restoreStash(); err != nil {
+			slog.Error("failed to restore the stash", "error", err)
+			h.lastOutput += fmt.Sprintf("\n%sThe uncommitted changes are still stashed, restore them with 'git stash pop': %v%s\n", colorRed, err, colorReset)
+		}
+		h.stashed = false
+	}
+	app.Stop()
+	return false
+}
+
+// foldIntoRange folds the changes of the working tree into the "Diff on"
+// commit, which is selected again once rewritten.
+func (h *handler) foldIntoRange(app *tui.App) error {
+	folded, err := foldChanges(h.diffOnCommit.Hash.String())
+	if err != nil {
+		return err
+	}
+	repo, err := openRepo()
+	if err != nil {
+		return err
+	}
+	on, err := resolveCommit(repo, folded)
+	if err != nil {
+		return err
+	}
+	slog.Info("folded the uncommitted changes", "commit", folded)
+	// The reloaded commit panels keep the selected commit
+	*h.diffOnCommit = commit{on}
+	h.refresh(app)
+	return nil
+}
+
+// handleWorktree applies the choice of the user for the changes of the
+// working tree, then resumes the submission from the previous panel.
+func (h *handler) handleWorktree(app *tui.App, previous string, choice worktreeChoice) {
+	h.showWorktree = false
+	h.layoutRight()
+	h.refocus(app, previous)
+	var err error
+	switch choice {
+	case worktreeCancel:
+		return
+	case worktreeStash:
+		if err = stashWorktree(); err == nil {
+			h.stashed = true
+		}
+	case worktreeFold:
+		err = h.foldIntoRange(app)
+	}
+	if err != nil {
+		slog.Error("failed to set the uncommitted changes aside", "error", err)
+		h.notify(colorRed + "Failed to set the changes aside, see the logs" + colorReset)
+		return
+	}
+	slog.Info("handled uncommitted changes", "choice", choice)
+	h.worktreeOK = true
+	h.submit(app)
+}
+
+// restack rebases the descendants of the commit replaced by "Diff on" once
+// confirmed, then shows the queued updates of their revisions.
+func (h *handler) restack(app *tui.App) (redraw bool) {
+	if h.diffOnCommit.Commit == nil {
+		return false
+	}
+	amended := h.diffOnCommit.Hash.String()
+	repo, err := openRepo()
+	if err == nil {
+		_, _, err = findReplaced(repo, h.diffOnCommit.Commit)
+	}
+	if err != nil {
+		slog.Warn("nothing to restack", "commit", amended, "error", err)
+		h.notify(colorYellow + err.Error() + colorReset)
+		return true
+	}
+	h.confirm("Restack the commits above the previous version of "+short(amended)+"?", func() {
+		branch, ops, err := restack(amended)
+		var conflict *restackConflict
+		switch {
+		case errors.As(err, &conflict):
+			slog.Warn("restack stopped on conflicts", "branch", branch, "files", conflict.files)
+			h.lastOutput = err.Error() + "\n"
+			app.Stop()
+			return
+		case err != nil:
+			slog.Error("failed to restack", "commit", amended, "error", err)
+			h.notify(colorRed + "Failed to restack: " + err.Error() + colorReset)
+			return
+		}
+		slog.Info("restacked", "branch", branch, "onto", amended, "queued", len(ops))
+		h.panels.queue.setQueue(branch, ops)
+		h.panels.queue.onRun = func() {
+			var output strings.Builder
+			if err := runQueue(h.backend, &output); err != nil {
+				slog.Error("failed to run the queue", "error", err)
+				output.WriteString(err.Error() + "\n")
+			}
+			h.lastOutput = output.String()
+			app.Stop()
+		}
+		previous := h.focused
+		h.panels.queue.onClose = func() {
+			h.showQueue = false
+			h.layoutRight()
+			h.refocus(app, previous)
+		}
+		h.showQueue = true
+		h.layoutRight()
+		app.Relayout()
+		app.FocusPanel(h.panels.queue.Title)
+		h.refresh(app)
+	})
+	return true
+}
+
+// land merges the selected revision once confirmed.
+func (h *handler) land(app *tui.App) (redraw bool) {
+	id := h.diffToUpdate.id
+	if id == "" {
+		return false
+	}
+	h.confirm("Land "+id+"?", func() {
+		op := &operation{Command: Land, Revision: id}
+		output, err := op.run(h.backend)
+		if err != nil {
+			slog.Error("failed to land", "revision", id, "error", err, "output", string(output))
+			h.notify(colorRed + "Failed to land " + id + colorReset)
+		} else {
+			slog.Info("landed", "revision", id, "output", string(output))
+			h.notify("Landed " + id)
+		}
+		if err := recordOperation(op); err != nil {
+			slog.Error("failed to record operation", "error", err)
+		}
+		h.refresh(app)
+	})
+	return true
+}
+
+// phabricatorOnly reports whether the backend is Phabricator, telling
+// the user that feature needs it otherwise.
+func (h *handler) phabricatorOnly(feature string) bool {
+	if h.backend.Name() == phabricatorName {
+		return true
+	}
+	h.notify(fmt.Sprintf("%s%s needs the Phabricator backend%s", colorYellow, feature, colorReset))
+	return false
+}
+
+// editMessage opens the message of the active command in the user's editor.
+func (h *handler) editMessage(app *tui.App) (redraw bool) {
+	panel := h.messagePanel()
+	var text string
+	err := app.Suspend(func() error {
+		var err error
+		text, err = editText(*panel.msg)
+		return err
+	})
+	if err != nil {
+		slog.Error("failed to edit message", "error", err)
+		return true
+	}
+	panel.SetText(text)
+	return true
+}
+
+// messagePanel returns the message panel of the active command.
+func (h *handler) messagePanel() *messagePanel {
+	if h.activeCommand == Create {
+		return &h.panels.createMsg
+	}
+	return &h.panels.updateMsg
+}
+
+// prefillMessage replaces the message of the active command by one generated
+// from the commits of the range. Unless force is set, a message edited by
+// the user is kept.
+func (h *handler) prefillMessage(force bool) (redraw bool) {
+	if h.activeCommand == Inbox {
+		return false
+	}
+	panel := h.messagePanel()
+	current := *panel.msg
+	untouched := current == "" || current == createTemplate || current == h.prefilled[h.activeCommand]
+	if !force && !untouched {
+		return false
+	}
+	if id := h.diffToUpdate.id; h.activeCommand == Update && id != "" && h.backend.Name() == phabricatorName {
+		if _, ok := h.uploaded[id]; !ok {
+			h.loadUploaded(id, force)
+			return false
+		}
+	}
+	text, err := h.generateMessage()
+	if err != nil {
+		slog.Warn("failed to generate the message", "command", h.activeCommand, "error", err)
+		if force {
+			h.notify(colorYellow + "Failed to generate the message: " + err.Error() + colorReset)
+		}
+		return true
+	}
+	if text == "" {
+		return force
+	}
+	if h.prefilled == nil {
+		h.prefilled = map[command]string{}
+	}
+	h.prefilled[h.activeCommand] = text
+	panel.SetText(text)
+	return true
+}
+
+// loadUploaded fetches the commits of the latest diff of the revision id in
+// the background, then prefills the message with them if id is still the
+// revision to update.
+func (h *handler) loadUploaded(id string, force bool) {
+	if h.loadingUploaded[id] {
+		return
+	}
+	if h.loadingUploaded == nil {
+		h.loadingUploaded = map[string]bool{}
+	}
+	h.loadingUploaded[id] = true
+	app := h.app
+	go func() {
+		uploaded, err := latestDiffCommits(id)
+		app.Post(func() {
+			delete(h.loadingUploaded, id)
+			if err != nil {
+				slog.Warn("failed to get the latest diff", "revision", id, "error", err)
+				if force {
+					h.notify(colorYellow + "Failed to generate the message: " + err.Error() + colorReset)
+				}
+				return
+			}
+			if h.uploaded == nil {
+				h.uploaded = map[string][]uploadedCommit{}
+			}
+			h.uploaded[id] = uploaded
+			if h.diffToUpdate.id == id {
+				h.prefillMessage(force)
+			}
+		})
+	}()
+}
+
+// generateMessage describes the range: the title and summary of its first
+// commit for a new revision, the commits missing from the latest diff of
+// the revision to update, as loaded by loadUploaded, otherwise.
+func (h *handler) generateMessage() (string, error) {
+	if h.diffFromCommit.Commit == nil || h.diffOnCommit.Commit == nil {
+		return "", errNoRange
+	}
+	commits, err := rangeCommits(h.diffFromCommit.Commit, h.diffOnCommit.Commit)
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", errors.New("empty range")
+	}
+	if h.activeCommand == Create {
+		return createMessage(commits[0]), nil
+	}
+	return updateMessage(commits, h.uploaded[h.diffToUpdate.id]), nil
+}
+
+// arcFlags returns the flags shared by every arc diff invocation.
+func (h *handler) arcFlags() []string {
+	flags := h.panels.options.options.args()
+	if h.checks != nil && h.diffOnCommit.Commit != nil && h.checks.hash == h.diffOnCommit.Hash.String() {
+		flags = mergeFlags(flags, h.checks.arcFlags()...)
+	}
+	return flags
+}
+
+// errNoRange tells that the commits of the range are not loaded or selected.
+var errNoRange = errors.New("no range selected")
+
+// operation describes the review operation of the active command.
+func (h *handler) operation() (*operation, error) {
+	if h.diffFromCommit.Commit == nil || h.diffOnCommit.Commit == nil {
+		return nil, errNoRange
+	}
+	op := &operation{
+		Command: h.activeCommand,
+		From:    h.diffFromCommit.Hash.String(),
+		On:      h.diffOnCommit.Hash.String(),
+		Flags:   h.arcFlags(),
+	}
+	switch h.activeCommand {
+	case Create:
+		op.Message = *h.createMsg
+	default:
+		op.Revision = h.diffToUpdate.id
+		op.Message = *h.updateMsg
 	}
-	cmd := exec.Command(
-		"arc",
-		"diff", h.diffFromCommit.Hash.String(),
-		"--head", h.diffOnCommit.Hash.String(),
-		"--update", h.diffToUpdate.id,
-		"--message", *h.updateMsg,
-	)
-	return cmd.CombinedOutput()
+	return op, nil
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// operation is a review operation performed by bow, as recorded in the history.
+type operation struct {
+	Time    time.Time `json:"time"`
+	Command command   `json:"command"`
+	// Backend is the name of the review backend, Phabricator when empty.
+	Backend string `json:"backend,omitempty"`
+	// Root is the top level directory of the repository the operation ran in.
+	Root     string   `json:"root,omitempty"`
+	Flags    []string `json:"flags,omitempty"`
+	From     string   `json:"from,omitempty"`
+	On       string   `json:"on,omitempty"`
+	Revision string   `json:"revision,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	// Transactions are the differential.revision.edit transactions of an Edit.
+	Transactions []map[string]any `json:"transactions,omitempty"`
+	ExitCode     int              `json:"exit_code"`
+	Output       string           `json:"output"`
+}
+
+// run executes the operation on backend and stores its time, repository,
+// exit status and output. An operation already run, like a rerun, fails
+// outside of the repository it ran in.
+func (op *operation) run(backend ReviewBackend) ([]byte, error) {
+	op.Time = time.Now()
+	op.Backend = backend.Name()
+	var output []byte
+	root, err := repoRoot()
+	switch {
+	case err != nil:
+	case op.Root != "" && op.Root != root:
+		err = fmt.Errorf("the operation ran in %s, not in %s", op.Root, root)
+	case op.Command == Create:
+		output, err = backend.Create(op.From, op.On, op.Message, op.Flags)
+	case op.Command == Land:
+		output, err = backend.Land(op.Revision)
+	case op.Command == Edit:
+		if err = editRevision(op.Revision, op.Transactions...); err == nil {
+			output = []byte(fmt.Sprintf("Edited %s: %s\n", op.Revision, strings.Join(op.actions(), ", ")))
+		}
+	default:
+		output, err = backend.Update(op.Revision, op.From, op.On, op.Message, op.Flags)
+	}
+	if op.Root == "" {
+		op.Root = root
+	}
+	op.Output = string(output)
+	op.ExitCode = 0
+	if err != nil {
+		if op.Output == "" {
+			op.Output = err.Error() + "\n"
+		}
+		op.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			op.ExitCode = exitErr.ExitCode()
+		}
+	}
+	return output, err
+}
+
+// actions returns the types of the transactions of an Edit.
+func (op *operation) actions() []string {
+	var actions []string
+	for _, t := range op.Transactions {
+		actions = append(actions, fmt.Sprint(t["type"]))
+	}
+	return actions
+}
+
+func (op *operation) String() string {
+	target := op.Revision
+	if target == "" {
+		target = "new"
+	}
+	what := short(op.From) + ".." + short(op.On)
+	if op.Command == Edit {
+		what = strings.Join(op.actions(), ",")
+	}
+	return fmt.Sprintf("%s  %-6s  %-7s  %s  exit %d",
+		op.Time.Format("2006-01-02 15:04:05"), op.Command, target, what, op.ExitCode)
+}
+
+// short returns the abbreviated form of a commit hash.
+func short(hash string) string {
+	if len(hash) > 6 {
+		return hash[:6]
+	}
+	return hash
+}
+
+func historyPath() string {
+	return filepath.Join(cacheDir(), "history.jsonl")
+}
+
+// recordOperation appends op to the history.
+func recordOperation(op *operation) error {
+	line, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to encode operation: %w", err)
+	}
+	file, err := os.OpenFile(historyPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// readHistory returns the recorded operations, oldest first.
+func readHistory() ([]operation, error) {
+	return readOperations(historyPath())
+}
+
+// readOperations reads a file of operations, one JSON object per line.
+// A missing file holds no operation.
+func readOperations(path string) ([]operation, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var ops []operation
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var op operation
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ops, nil
+}
+
+const historyUsage = `usage: bow history [list]
+       bow history show <n>
+       bow history rerun <n>
+`
+
+// runHistory implements the history subcommand and returns the exit code.
+func runHistory(args []string, out io.Writer) int {
+	ops, err := readHistory()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(args) == 0 || args[0] == "list" {
+		for i, op := range ops {
+			_, _ = fmt.Fprintf(out, "%4d  %s\n", i+1, op.String())
+		}
+		return 0
+	}
+	if len(args) != 2 {
+		fmt.Fprint(os.Stderr, historyUsage)
+		return 2
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 || n > len(ops) {
+		fmt.Fprintf(os.Stderr, "no operation %q in history\n", args[1])
+		return 1
+	}
+	op := ops[n-1]
+
+	switch args[0] {
+	case "show":
+		_, _ = fmt.Fprintf(out, "Time:     %s\n", op.Time.Format(time.RFC3339))
+		_, _ = fmt.Fprintf(out, "Command:  %s\n", op.Command)
+		_, _ = fmt.Fprintf(out, "Backend:  %s\n", cmp.Or(op.Backend, phabricatorName))
+		_, _ = fmt.Fprintf(out, "Root:     %s\n", op.Root)
+		_, _ = fmt.Fprintf(out, "Flags:    %s\n", strings.Join(op.Flags, " "))
+		_, _ = fmt.Fprintf(out, "From:     %s\n", op.From)
+		_, _ = fmt.Fprintf(out, "On:       %s\n", op.On)
+		if op.Revision != "" {
+			_, _ = fmt.Fprintf(out, "Revision: %s\n", op.Revision)
+		}
+		if op.Message != "" {
+			_, _ = fmt.Fprintf(out, "Message:\n%s\n", op.Message)
+		}
+		for _, t := range op.Transactions {
+			_, _ = fmt.Fprintf(out, "Action:   %v", t["type"])
+			if text, ok := t["value"].(string); ok {
+				_, _ = fmt.Fprintf(out, "\n%s", text)
+			}
+			_, _ = fmt.Fprintln(out)
+		}
+		_, _ = fmt.Fprintf(out, "Exit:     %d\n\n%s", op.ExitCode, op.Output)
+		return 0
+	case "rerun":
+		// The range and the configuration are the ones of the repository
+		// the operation ran in
+		if err := os.Chdir(op.Root); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to enter the repository of the operation: %v\n", err)
+			return 1
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		cfg.Backend = op.Backend
+		backend, err := newBackend(cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		output, err := op.run(backend)
+		if recErr := recordOperation(&op); recErr != nil {
+			fmt.Fprintln(os.Stderr, recErr)
+		}
+		_, _ = out.Write(output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprint(os.Stderr, historyUsage)
+		return 2
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"app/tui"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// review is a revision waiting on the review of the user.
+type review struct {
+	id      string
+	phid    string
+	title   string
+	author  string
+	status  string
+	created time.Time
+}
+
+func (r review) String() string {
+	return fmt.Sprintf("%s%s%s %5s  %s: %s", colorYellow, r.id, colorReset, age(time.Since(r.created)), r.author, r.title)
+}
+
+// age formats d as a short duration like 3d or 5h.
+func age(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+type revisionSearchResult struct {
+	Data   []revisionSearchData `json:"data"`
+	Cursor searchCursor         `json:"cursor"`
+}
+
+// revisionSearchData is a revision of a differential.revision.search result.
+type revisionSearchData struct {
+	ID     int    `json:"id"`
+	PHID   string `json:"phid"`
+	Fields struct {
+		Title      string `json:"title"`
+		AuthorPHID string `json:"authorPHID"`
+		Status     struct {
+			Value string `json:"value"`
+			Name  string `json:"name"`
+		} `json:"status"`
+		DateCreated int64 `json:"dateCreated"`
+	} `json:"fields"`
+	Attachments struct {
+		Reviewers struct {
+			Reviewers []struct {
+				ReviewerPHID string `json:"reviewerPHID"`
+				Status       string `json:"status"`
+			} `json:"reviewers"`
+		} `json:"reviewers"`
+	} `json:"attachments"`
+}
+
+// parseReviews converts a differential.revision.search result to reviews, oldest first.
+func parseReviews(result revisionSearchResult, names map[string]string) []review {
+	var reviews []review
+	for _, rev := range result.Data {
+		reviews = append(reviews, review{
+			id:      fmt.Sprintf("D%d", rev.ID),
+			phid:    rev.PHID,
+			title:   rev.Fields.Title,
+			author:  names[rev.Fields.AuthorPHID],
+			status:  rev.Fields.Status.Name,
+			created: time.Unix(rev.Fields.DateCreated, 0),
+		})
+	}
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].created.Before(reviews[j].created)
+	})
+	return reviews
+}
+
+// keepAwaiting keeps the revisions of result still waiting on the review of
+// me: not authored by me, not already accepted or rejected by me, and with
+// one of the responsible reviewers, me or my projects, yet to act.
+func keepAwaiting(result *revisionSearchResult, me string, responsible []string) {
+	result.Data = slices.DeleteFunc(result.Data, func(rev revisionSearchData) bool {
+		if rev.Fields.AuthorPHID == me {
+			return true
+		}
+		awaiting := false
+		for _, r := range rev.Attachments.Reviewers.Reviewers {
+			switch {
+			case r.ReviewerPHID == me && (r.Status == "accepted" || r.Status == "rejected"):
+				return true
+			case slices.Contains(responsible, r.ReviewerPHID) && (r.Status == "added" || r.Status == "blocking"):
+				awaiting = true
+			}
+		}
+		return !awaiting
+	})
+}
+
+// whoami returns the PHID of the user arc is authenticated as.
+func whoami() (string, error) {
+	var result struct {
+		PHID string `json:"phid"`
+	}
+	if err := callConduit("user.whoami", map[string]any{}, &result); err != nil {
+		return "", err
+	}
+	return result.PHID, nil
+}
+
+// getInbox fetches the open revisions needing the review of the user or of
+// one of the projects they are a member of.
+func getInbox() ([]review, error) {
+	if isDevMode() {
+		return []review{
+			{id: "D00010", title: "Fix the parser", author: "alice", status: "Needs Review", created: time.Now().Add(-72 * time.Hour)},
+			{id: "D00011", title: "Add a cache", author: "bob", status: "Needs Review", created: time.Now().Add(-5 * time.Hour)},
+		}, nil
+	}
+
+	me, err := whoami()
+	if err != nil {
+		return nil, err
+	}
+	reviewers := []string{me}
+	var projects struct {
+		Data []struct {
+			PHID string `json:"phid"`
+		} `json:"data"`
+	}
+	params := map[string]any{"constraints": map[string]any{"members": []string{me}}}
+	if err := callConduit("project.search", params, &projects); err != nil {
+		return nil, err
+	}
+	for _, project := range projects.Data {
+		reviewers = append(reviewers, project.PHID)
+	}
+
+	// The revisions the user is responsible for, as in the "Ready to Review"
+	// bucket of Phabricator, also list the ones they already reviewed or
+	// authored, filtered out by their reviewers.
+	var result revisionSearchResult
+	params = map[string]any{
+		"constraints": map[string]any{
+			"responsiblePHIDs": []string{me},
+			"statuses":         []string{"needs-review"},
+		},
+		"attachments": map[string]any{"reviewers": true},
+	}
+	for {
+		var page revisionSearchResult
+		if err := callConduit("differential.revision.search", params, &page); err != nil {
+			return nil, err
+		}
+		result.Data = append(result.Data, page.Data...)
+		if page.Cursor.After == nil {
+			break
+		}
+		params["after"] = *page.Cursor.After
+	}
+	keepAwaiting(&result, me, reviewers)
+
+	var authors []string
+	for _, rev := range result.Data {
+		authors = append(authors, rev.Fields.AuthorPHID)
+	}
+	names, err := getUsernames(authors)
+	if err != nil {
+		return nil, err
+	}
+	return parseReviews(result, names), nil
+}
+
+// editRevision applies the transactions to the revision with the given id, like D12345.
+func editRevision(id string, transactions ...map[string]any) error {
+	if isDevMode() {
+		return nil
+	}
+	params := map[string]any{
+		"objectIdentifier": id,
+		"transactions":     transactions,
+	}
+	return callConduit("differential.revision.edit", params, nil)
+}
+
+type inboxPanel struct {
+	*tui.ListPanel[review]
+	review *review
+	// loading is set while the inbox is fetched, loadErr tells why it last failed.
+	loading bool
+	loadErr error
+}
+
+func (ip *inboxPanel) Draw(_ bool) string {
+	var buffer bytes.Buffer
+	if text, ok := placeholder("the inbox", ip.loading, ip.loadErr); ok && (ip.loadErr != nil || len(ip.Items) == 0) {
+		buffer.WriteString(text + "\n")
+	} else if len(ip.Items) == 0 {
+		buffer.WriteString("Nothing to review\n")
+	}
+	for i, item := range ip.Items {
+		selected := ""
+		if ip.Selected == i {
+			selected = colorRed + "*" + colorReset
+		}
+		buffer.WriteString(fmt.Sprintf("%s %s\n", selected, item.String()))
+	}
+	buffer.WriteString("\na: accept  •  x: request changes  •  m: comment")
+	return buffer.String()
+}
+
+func (ip *inboxPanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
+	handled, redraw = ip.ListPanel.Update(msg)
+	ip.sync()
+	return handled, redraw
+}
+
+// setItems replaces the reviews and selects the first one.
+func (ip *inboxPanel) setItems(reviews []review) {
+	ip.Items = reviews
+	ip.Selected = 0
+	ip.sync()
+}
+
+func (ip *inboxPanel) sync() {
+	*ip.review = review{}
+	if len(ip.Items) > 0 && ip.Selected >= 0 && ip.Selected < len(ip.Items) {
+		*ip.review = ip.Items[ip.Selected]
+	}
+}
+
+func newInboxPanel(name string) inboxPanel {
+	return inboxPanel{
+		ListPanel: &tui.ListPanel[review]{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+		review: &review{},
+	}
+}
+
+// loadInbox fetches the revisions waiting on the user into the inbox panel
+// in the background. It does nothing if they are already being fetched.
+func (h *handler) loadInbox(app *tui.App) {
+	panel := &h.panels.inbox
+	if panel.loading {
+		return
+	}
+	panel.loading = true
+	go func() {
+		reviews, err := getInbox()
+		app.Post(func() {
+			panel.loading, panel.loadErr = false, err
+			if err != nil {
+				slog.Error("failed to get inbox", "error", err)
+				return
+			}
+			panel.setItems(reviews)
+		})
+	}()
+}
+
+// edit applies the transactions to the revision id and records the edit
+// in the history.
+func (h *handler) edit(id string, transactions ...map[string]any) error {
+	op := &operation{Command: Edit, Revision: id, Transactions: transactions}
+	_, err := op.run(h.backend)
+	if err := recordOperation(op); err != nil {
+		slog.Error("failed to record operation", "error", err)
+	}
+	return err
+}
+
+var reviewActionNames = map[string]string{
+	"accept":  "Accept",
+	"reject":  "Request changes on",
+	"comment": "Comment on",
+}
+
+// reviewAction applies the accept, reject or comment action to the selected
+// review after confirmation. Comments are written in the editor.
+func (h *handler) reviewAction(app *tui.App, action string) (redraw bool) {
+	rev := *h.panels.inbox.review
+	if rev.id == "" {
+		return false
+	}
+
+	var transactions []map[string]any
+	if action == "comment" {
+		var text string
+		err := app.Suspend(func() error {
+			var err error
+			text, err = editText("")
+			return err
+		})
+		if err != nil {
+			slog.Error("failed to edit comment", "error", err)
+			return true
+		}
+		if text == "" {
+			return true
+		}
+		transactions = append(transactions, map[string]any{"type": "comment", "value": text})
+	} else {
+		transactions = append(transactions, map[string]any{"type": action, "value": true})
+	}
+
+	name := reviewActionNames[action]
+	h.confirm(fmt.Sprintf("%s %s?", name, rev.id), func() {
+		go func() {
+			err := h.edit(rev.id, transactions...)
+			app.Post(func() {
+				if err != nil {
+					slog.Error("failed to edit revision", "revision", rev.id, "action", action, "error", err)
+					h.notify(fmt.Sprintf("%sFailed to %s %s: %v%s", colorRed, strings.ToLower(name), rev.id, err, colorReset))
+					return
+				}
+				slog.Info("edited revision", "revision", rev.id, "action", action)
+				h.loadInbox(app)
+			})
+		}()
+	})
+	return true
+}
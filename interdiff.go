@@ -0,0 +1,307 @@
+package main
+
+import (
+	"app/tui"
+	"cmp"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// filePatch is the part of a unified diff changing one file.
+type filePatch struct {
+	path string
+	// changes are the removed (-) and added (+) lines of the hunks. The
+	// context lines and the hunk headers are left out: arc uploads diffs
+	// with the whole files as context, and a rebase moves the lines.
+	changes []string
+}
+
+var (
+	fileHeaderRe = regexp.MustCompile(`^diff --git a/(.*) b/(.*)$`)
+	hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`)
+)
+
+// parsePatch splits a git unified diff by file, in order.
+func parsePatch(patch string) []filePatch {
+	var files []filePatch
+	inHunks := false
+	for line := range strings.SplitSeq(patch, "\n") {
+		if matches := fileHeaderRe.FindStringSubmatch(line); matches != nil {
+			path := matches[2]
+			if path == "/dev/null" {
+				path = matches[1]
+			}
+			files = append(files, filePatch{path: path})
+			inHunks = false
+			continue
+		}
+		if len(files) == 0 {
+			continue
+		}
+		current := &files[len(files)-1]
+		switch {
+		case hunkHeaderRe.MatchString(line):
+			inHunks = true
+		case strings.HasPrefix(line, "Binary files "):
+			current.changes = append(current.changes, line)
+		case inHunks && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			current.changes = append(current.changes, line)
+		}
+	}
+	return files
+}
+
+// interdiffStatus tells how the change of a file differs from the uploaded one.
+type interdiffStatus int
+
+const (
+	interdiffSame interdiffStatus = iota
+	interdiffChanged
+	interdiffAdded
+	interdiffDropped
+)
+
+// interdiffFile compares the change of a file between the uploaded diff and the local range.
+type interdiffFile struct {
+	path   string
+	status interdiffStatus
+	// lines are the lines of the uploaded change removed (-) or added (+)
+	// by the local one, around the changed lines both share, for a changed
+	// file.
+	lines []string
+}
+
+// interdiffContext is the number of unchanged lines shown around the changes of a file.
+const interdiffContext = 1
+
+// computeInterdiff compares the uploaded patch to the local one file by file, sorted by path.
+func computeInterdiff(uploaded, local string) []interdiffFile {
+	before := map[string]filePatch{}
+	for _, f := range parsePatch(uploaded) {
+		before[f.path] = f
+	}
+	after := map[string]filePatch{}
+	for _, f := range parsePatch(local) {
+		after[f.path] = f
+	}
+
+	var files []interdiffFile
+	for path, f := range after {
+		old, ok := before[path]
+		switch {
+		case !ok:
+			files = append(files, interdiffFile{path: path, status: interdiffAdded})
+		case slices.Equal(old.changes, f.changes):
+			files = append(files, interdiffFile{path: path, status: interdiffSame})
+		default:
+			files = append(files, interdiffFile{path: path, status: interdiffChanged, lines: diffLines(old.changes, f.changes)})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			files = append(files, interdiffFile{path: path, status: interdiffDropped})
+		}
+	}
+	slices.SortFunc(files, func(a, b interdiffFile) int { return cmp.Compare(a.path, b.path) })
+	return files
+}
+
+// diffLines returns the lines of a removed (-) or added (+) to get b, with
+// interdiffContext lines of context around them and … for the skipped ones.
+func diffLines(a, b []string) []string {
+	type line struct {
+		op   byte
+		text string
+	}
+	dmp := diffmatchpatch.New()
+	runesA, runesB, lineArray := dmp.DiffLinesToRunes(strings.Join(a, "\n")+"\n", strings.Join(b, "\n")+"\n")
+	var all []line
+	for _, d := range dmp.DiffCharsToLines(dmp.DiffMainRunes(runesA, runesB, false), lineArray) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for text := range strings.SplitSeq(strings.TrimSuffix(d.Text, "\n"), "\n") {
+			all = append(all, line{op: op, text: text})
+		}
+	}
+
+	var lines []string
+	skipped := false
+	for i, l := range all {
+		near := false
+		for j := max(0, i-interdiffContext); j <= min(len(all)-1, i+interdiffContext); j++ {
+			near = near || all[j].op != ' '
+		}
+		if !near {
+			skipped = true
+			continue
+		}
+		if skipped && len(lines) > 0 {
+			lines = append(lines, " …")
+		}
+		skipped = false
+		lines = append(lines, string(l.op)+l.text)
+	}
+	return lines
+}
+
+// getRawDiff fetches the raw patch of the diff id.
+func getRawDiff(id string) (string, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid diff %q", id)
+	}
+	var patch string
+	if err := callConduit("differential.getrawdiff", map[string]any{"diffID": n}, &patch); err != nil {
+		return "", err
+	}
+	return patch, nil
+}
+
+// latestRawDiff fetches the raw patch of the latest diff of the revision id.
+func latestRawDiff(id string) (string, error) {
+	if isDevMode() {
+		return "", nil
+	}
+	latest, err := queryLatestDiff(id)
+	if err != nil {
+		return "", err
+	}
+	if latest == nil {
+		return "", fmt.Errorf("%s has no diff", id)
+	}
+	return getRawDiff(latest.ID)
+}
+
+// getInterdiff compares the uploaded patch to the from..on range. In dev
+// mode, the range is compared to itself.
+func getInterdiff(uploaded string, from, on commit) ([]interdiffFile, error) {
+	if from.Commit == nil || on.Commit == nil {
+		return nil, errNoRange
+	}
+	patch, err := rangePatch(from.Commit, on.Commit)
+	if err != nil {
+		return nil, err
+	}
+	local := patch.String()
+	if isDevMode() {
+		uploaded = local
+	}
+	return computeInterdiff(uploaded, local), nil
+}
+
+// renderInterdiff describes the files changed since the uploaded diff, then
+// counts the unchanged ones.
+func renderInterdiff(files []interdiffFile) []string {
+	var lines []string
+	same := 0
+	for _, f := range files {
+		switch f.status {
+		case interdiffSame:
+			same++
+		case interdiffAdded:
+			lines = append(lines, fmt.Sprintf("%s+ %s%s  new in this update", colorGreen, f.path, colorReset))
+		case interdiffDropped:
+			lines = append(lines, fmt.Sprintf("%s- %s%s  dropped from this update", colorRed, f.path, colorReset))
+		case interdiffChanged:
+			lines = append(lines, fmt.Sprintf("%sM %s%s  changed since the last diff", colorYellow, f.path, colorReset))
+			for _, l := range f.lines {
+				switch l[0] {
+				case '-':
+					l = colorRed + l + colorReset
+				case '+':
+					l = colorGreen + l + colorReset
+				}
+				lines = append(lines, "    "+l)
+			}
+		}
+	}
+	if same > 0 {
+		lines = append(lines, fmt.Sprintf("%d files unchanged", same))
+	}
+	return lines
+}
+
+// rawDiff is the latest diff of a revision, or why it could not be fetched.
+type rawDiff struct {
+	patch string
+	err   error
+}
+
+// interdiffPanel shows what updating the revision changes compared to its
+// latest diff. It follows the selected revision and range.
+type interdiffPanel struct {
+	*tui.InfoPanel
+	revision *diff
+	from, on *commit
+	// shown is the selection the lines describe.
+	shown string
+	// uploaded caches the latest diff of each revision, fetched by load in
+	// the background when missing.
+	uploaded map[string]rawDiff
+	load     func(id string)
+}
+
+func (ip *interdiffPanel) Draw(active bool) string {
+	id := ip.revision.id
+	if id == "" {
+		return "No revision selected"
+	}
+	raw, ok := ip.uploaded[id]
+	if !ok {
+		ip.load(id)
+		return fmt.Sprintf("Loading the latest diff of %s…", id)
+	}
+	selection := id
+	if ip.from.Commit != nil && ip.on.Commit != nil {
+		selection += " " + ip.from.Hash.String() + ".." + ip.on.Hash.String()
+	}
+	if selection != ip.shown {
+		ip.shown = selection
+		ip.setInterdiff(id, raw)
+	}
+	return ip.InfoPanel.Draw(active)
+}
+
+// setInterdiff shows the interdiff of the revision id, whose latest diff is raw.
+func (ip *interdiffPanel) setInterdiff(id string, raw rawDiff) {
+	err := raw.err
+	var files []interdiffFile
+	if err == nil {
+		files, err = getInterdiff(raw.patch, *ip.from, *ip.on)
+	}
+	if err != nil {
+		slog.Error("failed to get interdiff", "revision", id, "error", err)
+		ip.Lines = []string{fmt.Sprintf("Failed to compare the range to the latest diff of %s: %v", id, err)}
+		return
+	}
+	lines := []string{fmt.Sprintf("%s: the range compared to the latest diff, file by file", id), ""}
+	ip.Lines = append(lines, renderInterdiff(files)...)
+}
+
+// forget drops the cached latest diffs, fetched again when shown.
+func (ip *interdiffPanel) forget() {
+	ip.uploaded = nil
+	ip.shown = ""
+}
+
+func newInterdiffPanel(name string) interdiffPanel {
+	return interdiffPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/storer"
+)
+
+// launchTarget is what bow opens focused on, from its command line argument.
+// It is applied once the commits and the revisions are loaded.
+type launchTarget struct {
+	// revision is selected in "Diff to update" when set.
+	revision string
+	// rangeOf is the revision whose range is derived in the background,
+	// along with the commits, to set from and on.
+	rangeOf string
+	// from and on are selected in the commit panels when set.
+	from, on *object.Commit
+	// rangeErr tells why the range of revision could not be derived.
+	rangeErr error
+}
+
+var revisionArgRe = regexp.MustCompile(`^D\d+$`)
+
+// parseLaunchTarget resolves the argument bow is launched with: a revision
+// like D123, whose range is left to derive from its local commits, or a
+// from..on range of git revisions.
+func parseLaunchTarget(arg string) (*launchTarget, error) {
+	switch {
+	case revisionArgRe.MatchString(arg):
+		return &launchTarget{revision: arg, rangeOf: arg}, nil
+	case strings.Contains(arg, ".."):
+		repo, err := openRepo()
+		if err != nil {
+			return nil, err
+		}
+		from, on, err := resolveRange(repo, arg)
+		if err != nil {
+			return nil, err
+		}
+		return &launchTarget{from: from, on: on}, nil
+	}
+	return nil, fmt.Errorf("unknown argument %q, expected a revision like D123 or a <from>..<on> range", arg)
+}
+
+// revisionRange derives the range of the revision id from its local commits:
+// the commits below HEAD linked to it by their trailer, or else the commits
+// of its latest diff found in the repository, fetched from the backend.
+func revisionRange(id string) (from, on *object.Commit, err error) {
+	repo, err := openRepo()
+	if err != nil {
+		return nil, nil, err
+	}
+	n, _ := revisionNumber(id)
+	head, err := resolveCommit(repo, "HEAD")
+	if err != nil {
+		return nil, nil, err
+	}
+	var linked []*object.Commit
+	walked := 0
+	err = object.NewCommitPreorderIter(head, nil, nil).ForEach(func(c *object.Commit) error {
+		walked++
+		if walked > maxRangeWalk {
+			return storer.ErrStop
+		}
+		if m, ok := revisionNumber(revisionOf(c.Message)); ok && m == n {
+			linked = append(linked, c)
+		} else if len(linked) > 0 {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to walk the commits of HEAD: %w", err)
+	}
+
+	if len(linked) == 0 {
+		uploaded, err := latestDiffCommits(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, u := range uploaded {
+			if c, err := repo.CommitObject(plumbing.NewHash(u.hash)); err == nil {
+				linked = append(linked, c)
+			}
+		}
+		// Newest first, as found below HEAD
+		slices.SortFunc(linked, func(a, b *object.Commit) int {
+			return b.Committer.When.Compare(a.Committer.When)
+		})
+	}
+	if len(linked) == 0 {
+		return nil, nil, fmt.Errorf("no local commit of %s", id)
+	}
+	oldest := linked[len(linked)-1]
+	if oldest.NumParents() == 0 {
+		return nil, nil, fmt.Errorf("the commits of %s start at the root commit", id)
+	}
+	from, err = oldest.Parent(0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get parent of %s: %w", short(oldest.Hash.String()), err)
+	}
+	return from, linked[0], nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"app/tui"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// lifecycleAction changes the state of a revision through a
+// differential.revision.edit transaction.
+type lifecycleAction struct {
+	transaction string
+	// verb and done describe the action, as in "Abandon D123?" and "Abandoned D123".
+	verb string
+	done string
+	// status is the expected status once done, unchanged when 0.
+	status status
+}
+
+// lifecycleActions are the actions on the selected revision, by key.
+var lifecycleActions = map[rune]lifecycleAction{
+	'A': {transaction: "abandon", verb: "Abandon", done: "Abandoned", status: Abandoned},
+	'U': {transaction: "reclaim", verb: "Reclaim", done: "Reclaimed", status: NeedsReview},
+	'C': {transaction: "commandeer", verb: "Commandeer", done: "Commandeered"},
+	'P': {transaction: "plan-changes", verb: "Plan changes on", done: "Planned changes on", status: ChangesPlanned},
+	'V': {transaction: "request-review", verb: "Request review of", done: "Requested review of", status: NeedsReview},
+}
+
+// lifecycleActionOf returns the lifecycle action bound to the key of msg.
+func lifecycleActionOf(msg tui.InputMessage) (lifecycleAction, bool) {
+	for key, action := range lifecycleActions {
+		if msg.IsChar(key) {
+			return action, true
+		}
+	}
+	return lifecycleAction{}, false
+}
+
+// revisionStatus fetches the status of the revision id, 0 in dev mode.
+func revisionStatus(id string) (status, error) {
+	if isDevMode() {
+		return 0, nil
+	}
+	n, ok := revisionNumber(id)
+	if !ok {
+		return 0, fmt.Errorf("invalid revision %q", id)
+	}
+	nodes, err := searchRevisions(map[string]any{"ids": []int{n}})
+	if err != nil {
+		return 0, err
+	}
+	if len(nodes) == 0 {
+		return 0, fmt.Errorf("revision %s not found", id)
+	}
+	s, ok := stringToStatus[nodes[0].status]
+	if !ok {
+		return 0, fmt.Errorf("unknown status %q", nodes[0].status)
+	}
+	return s, nil
+}
+
+// editLifecycle applies action to the revision to update once confirmed,
+// in the background, then shows its new status in place.
+func (h *handler) editLifecycle(app *tui.App, action lifecycleAction) (redraw bool) {
+	id := h.diffToUpdate.id
+	if id == "" {
+		return false
+	}
+	if !h.phabricatorOnly("revision actions") {
+		return true
+	}
+	if h.editing[id] {
+		h.notify(colorYellow + "An action on " + id + " is still running" + colorReset)
+		return true
+	}
+	h.confirm(fmt.Sprintf("%s %s?", action.verb, id), func() {
+		if h.editing == nil {
+			h.editing = map[string]bool{}
+		}
+		h.editing[id] = true
+		go func() {
+			err := h.edit(id, map[string]any{"type": action.transaction, "value": true})
+			s := action.status
+			if err == nil {
+				if fetched, err := revisionStatus(id); err != nil {
+					slog.Warn("failed to fetch the status of the revision", "revision", id, "error", err)
+				} else if fetched != 0 {
+					s = fetched
+				}
+			}
+			app.Post(func() {
+				delete(h.editing, id)
+				if err != nil {
+					slog.Error("failed to edit revision", "revision", id, "action", action.transaction, "error", err)
+					h.notify(fmt.Sprintf("%sFailed to %s %s: %v%s", colorRed, strings.ToLower(action.verb), id, err, colorReset))
+					return
+				}
+				slog.Info("edited revision", "revision", id, "action", action.transaction)
+				if s != 0 {
+					h.panels.diffs.setStatus(id, s)
+				}
+				h.notify(action.done + " " + id)
+			})
+		}()
+	})
+	return true
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"app/tui"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxLogSize is the size after which the log file is rotated.
+	maxLogSize = 5 << 20
+	// maxLogBackups is the number of rotated log files kept, as bow.log.1, bow.log.2...
+	maxLogBackups = 3
+	// maxSessionLines is the number of records of the session kept for the log viewer.
+	maxSessionLines = 500
+)
+
+// parseLogLevel parses debug, info, warn or error.
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// envOr returns the environment variable key, or fallback when it is unset.
+func envOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// rotatingFile is a log file rotated once it grows over maxSize.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+func openRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+// rotate shifts bow.log.N to bow.log.N+1, dropping the oldest, and reopens the log.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", rf.path, maxLogBackups))
+	for i := maxLogBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Close()
+}
+
+// sessionLog keeps the last records of the session for the log viewer.
+type sessionLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (sl *sessionLog) Write(p []byte) (int, error) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	for line := range strings.SplitSeq(strings.TrimRight(string(p), "\n"), "\n") {
+		sl.lines = append(sl.lines, line)
+	}
+	if len(sl.lines) > maxSessionLines {
+		sl.lines = sl.lines[len(sl.lines)-maxSessionLines:]
+	}
+	return len(p), nil
+}
+
+// tail returns the last n records.
+func (sl *sessionLog) tail(n int) []string {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	start := max(0, len(sl.lines)-n)
+	return append([]string{}, sl.lines[start:]...)
+}
+
+// newSessionID returns a random identifier added to every record of the session.
+func newSessionID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// setupLogging sets the default logger to write records of at least level
+// to the rotated file at path and to the returned session log.
+func setupLogging(path string, level slog.Level) (*sessionLog, func(), error) {
+	file, err := openRotatingFile(path, maxLogSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	session := &sessionLog{}
+	handler := slog.NewTextHandler(multiWriter{file, session}, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler).With("session", newSessionID()))
+	return session, func() { _ = file.Close() }, nil
+}
+
+// multiWriter writes to every writer, returning the first error.
+// Unlike io.MultiWriter it keeps writing after a failure.
+type multiWriter []io.Writer
+
+func (mw multiWriter) Write(p []byte) (int, error) {
+	var firstErr error
+	for _, w := range mw {
+		if _, err := w.Write(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(p), firstErr
+}
+
+// logPanel shows the latest records of the session.
+type logPanel struct {
+	*tui.InfoPanel
+	session *sessionLog
+}
+
+func (lp *logPanel) Draw(active bool) string {
+	if lp.session == nil {
+		return "Logging to the session is disabled"
+	}
+	_, h := lp.Size()
+	lp.Lines = lp.session.tail(max(1, h-2))
+	return lp.InfoPanel.Draw(active)
+}
+
+func newLogPanel(name string, session *sessionLog) logPanel {
+	return logPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+		session: session,
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"app/tui"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,40 +15,109 @@ type panels struct {
 	diffs     diffPanel
 	updateMsg messagePanel
 	createMsg messagePanel
+	checks    checkPanel
+	scan      scanPanel
+	options   optionsPanel
+	comments  commentsPanel
+	stack     stackPanel
+	interdiff interdiffPanel
+	inbox     inboxPanel
+	detail    detailPanel
+	rangeSel  rangePanel
+	logs      logPanel
+	prompt    promptPanel
+	worktree  worktreePanel
+	queue     queuePanel
 }
 
 func createApp() (*tui.App, *handler, error) {
+	return createAppWith(tui.NewApp)
+}
 
-	commits, err := getCommits()
+// createAppWith is createApp opening the App with newApp, for example
+// tui.NewHeadlessApp wrapped to script a session.
+func createAppWith(newApp func(tui.Layout, tui.GlobalHandler) *tui.App) (*tui.App, *handler, error) {
+	cfg, err := loadConfig()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create app: %w", err)
 	}
-	diffs, err := getDiff()
+	backend, err := newBackend(cfg)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create app: %w", err)
 	}
+	// The commits and the revisions are loaded in the background by
+	// refresh. Until then, the panels show the cached revisions.
+	var commits []commit
+	diffs, err := readRevisionCache(backend.Name())
+	if err != nil {
+		slog.Warn("failed to read the revision cache", "error", err)
+	}
 
 	panels := panels{
 		diffFrom:  newCommitPanel("Diff from", commits),
-		diffOn:    newCommitPanel("Diff from", commits),
+		diffOn:    newCommitPanel("Diff on", commits),
 		diffs:     newDiffPanel("Diff to update", diffs),
 		updateMsg: newMessagePanelUpdate("Message"),
 		createMsg: newMessagePanelCreate("Message"),
+		checks:    newCheckPanel("Checks"),
+		scan:      newScanPanel("Scan"),
+		options:   newOptionsPanel("Options"),
+		comments:  newCommentsPanel("Comments"),
+		stack:     newStackPanel("Stack"),
+		interdiff: newInterdiffPanel("Interdiff"),
+		inbox:     newInboxPanel("Inbox"),
+		detail:    newDetailPanel("Commit"),
+		logs:      newLogPanel("Logs", nil),
+		prompt:    newPromptPanel(),
+		worktree:  newWorktreePanel("Working tree"),
+		queue:     newQueuePanel("Queue"),
 	}
+	panels.rangeSel = newRangePanel("Range", commits, panels.diffFrom.commit, panels.diffOn.commit)
+	panels.diffFrom.revisions = &panels.diffs.Items
+	panels.diffOn.revisions = &panels.diffs.Items
+	panels.rangeSel.revisions = &panels.diffs.Items
+	panels.diffFrom.loading = true
+	panels.diffOn.loading = true
+	panels.diffs.loading = true
 
+	handler := &handler{
+		diffFromCommit: panels.diffFrom.commit,
+		diffOnCommit:   panels.diffOn.commit,
+		diffToUpdate:   panels.diffs.diff,
+		updateMsg:      panels.updateMsg.msg,
+		createMsg:      panels.createMsg.msg,
+		panels:         panels,
+		activeCommand:  Update,
+		focused:        panels.diffFrom.Title,
+		config:         cfg,
+		backend:        backend,
+	}
+
+	handler.panels.detail.commit = handler.diffFromCommit
+	handler.panels.rangeSel.counted = &handler.rangeCount
+	handler.panels.interdiff.revision = handler.diffToUpdate
+	handler.panels.interdiff.from = handler.diffFromCommit
+	handler.panels.interdiff.on = handler.diffOnCommit
+	handler.panels.interdiff.load = handler.loadInterdiff
+	handler.panels.comments.revision = handler.diffToUpdate
+	handler.panels.comments.load = handler.loadComments
+	handler.panels.stack.revision = handler.diffToUpdate
+	handler.panels.stack.load = handler.loadStack
+
+	// The layout holds the panels of the handler, which it updates.
 	defaultLayout := &tui.HorizontalSplit{
 		Panels: []tui.Layout{
 			&tui.VerticalSplit{
 				Panels: []tui.Layout{
-					&tui.PanelNode{Panel: &panels.diffFrom, Weight: 1},
-					&tui.PanelNode{Panel: &panels.diffOn, Weight: 1},
+					&tui.PanelNode{Panel: &handler.panels.diffFrom, Weight: 1},
+					&tui.PanelNode{Panel: &handler.panels.diffOn, Weight: 1},
 				},
 				Weight: 2,
 			},
 			&tui.VerticalSplit{
 				Panels: []tui.Layout{
-					&tui.PanelNode{Panel: &panels.diffs, Weight: 1},
-					&tui.PanelNode{Panel: &panels.updateMsg, Weight: 0},
+					&tui.PanelNode{Panel: &handler.panels.diffs, Weight: 1},
+					&tui.PanelNode{Panel: &handler.panels.updateMsg, Weight: 0},
 				},
 				Weight: 1,
 			},
@@ -55,44 +125,76 @@ func createApp() (*tui.App, *handler, error) {
 		Weight: 1,
 	}
 
-	handler := &handler{
-		diffFromCommit: panels.diffFrom.commit,
-		diffOnCommit:   panels.diffOn.commit,
-		diffToUpdate:   panels.diffs.diff,
-		updateMsg:      panels.updateMsg.msg,
-		createMsg:      panels.createMsg.msg,
-		panels:         panels,
-		activeCommand:  Update,
-		rightPanel:     &defaultLayout.Panels[1],
-	}
+	handler.leftPanel = &defaultLayout.Panels[0]
+	handler.rightPanel = &defaultLayout.Panels[1]
 
-	app := tui.NewApp(defaultLayout, handler)
+	app := newApp(defaultLayout, handler)
+	handler.app = app
 
 	return app, handler, nil
 }
 
+// cacheDir returns the directory holding bow's logs and history.
+func cacheDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".cache", "bow")
+}
+
 func main() {
+	refresh := flag.Duration("refresh", 0, "reload the revisions and commits at this interval, 0 to disable")
+	logLevel := flag.String("log-level", envOr("BOW_LOG_LEVEL", "debug"), "minimum level of the logged records: debug, info, warn or error")
+	logFile := flag.String("log-file", envOr("BOW_LOG_FILE", filepath.Join(cacheDir(), "bow.log")), "path of the log file")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: bow [flags] [D<id> | <from>..<on>]\n       bow history|export|restack|queue [args]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	// Setup logging
-	cacheDir := filepath.Join(os.Getenv("HOME"), ".cache", "bow")
-	_ = os.MkdirAll(cacheDir, 0755)
-	logFile := filepath.Join(cacheDir, "bow.log")
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	_ = os.MkdirAll(cacheDir(), 0755)
+	level, err := parseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	session, closeLog, err := setupLogging(*logFile, level)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to open log file: %v\n", err)
 		os.Exit(1)
 	}
-	defer func() { _ = file.Close() }()
+	defer closeLog()
 
-	handler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
+	switch flag.Arg(0) {
+	case "history":
+		os.Exit(runHistory(flag.Args()[1:], os.Stdout))
+	case "export":
+		os.Exit(runExport(flag.Args()[1:], os.Stdout))
+	case "restack":
+		os.Exit(runRestack(flag.Args()[1:], os.Stdout))
+	case "queue":
+		os.Exit(runQueueCommand(flag.Args()[1:], os.Stdout))
+	}
+
+	var launch *launchTarget
+	if flag.NArg() > 0 {
+		launch, err = parseLaunchTarget(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
 
 	app, h, err := createApp()
 	if err != nil {
 		slog.Error("failed to start application", "error", err)
 		os.Exit(1)
 	}
+	h.launch = launch
 
+	h.panels.logs.session = session
+	h.refresh(app)
+	if *refresh > 0 {
+		h.poll(app, *refresh)
+	}
 	app.Run()
 
 	if h.lastOutput != "" {
@@ -2,8 +2,132 @@ package main
 
 import (
 	"app/tui"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/object"
 )
 
+// createTemplate is the empty message of a revision to create.
+const createTemplate = "\n\nSummary: \n\nTest case: \n\nReviewers: \n\n"
+
+// uploadedCommit is a commit of a diff uploaded by arc.
+type uploadedCommit struct {
+	hash    string
+	subject string
+}
+
+type queriedDiff struct {
+	ID         string          `json:"id"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+type diffProperties struct {
+	LocalCommits map[string]struct {
+		Commit  string `json:"commit"`
+		Summary string `json:"summary"`
+		Message string `json:"message"`
+	} `json:"local:commits"`
+}
+
+// isObject reports whether raw is a JSON object. Conduit encodes empty
+// objects as empty arrays.
+func isObject(raw json.RawMessage) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
+
+// latestQueriedDiff returns the latest diff of a differential.querydiffs
+// result, nil when there is none.
+func latestQueriedDiff(result json.RawMessage) (*queriedDiff, error) {
+	if !isObject(result) {
+		return nil, nil
+	}
+	var diffs map[string]queriedDiff
+	if err := json.Unmarshal(result, &diffs); err != nil {
+		return nil, fmt.Errorf("failed to decode diffs: %w", err)
+	}
+	var latest *queriedDiff
+	latestID := -1
+	for _, d := range diffs {
+		if id, err := strconv.Atoi(d.ID); err == nil && id > latestID {
+			latest, latestID = &d, id
+		}
+	}
+	return latest, nil
+}
+
+// queryLatestDiff fetches the latest diff of the revision id, nil when it has none.
+func queryLatestDiff(id string) (*queriedDiff, error) {
+	n, ok := revisionNumber(id)
+	if !ok {
+		return nil, fmt.Errorf("invalid revision %q", id)
+	}
+	var result json.RawMessage
+	if err := callConduit("differential.querydiffs", map[string]any{"revisionIDs": []int{n}}, &result); err != nil {
+		return nil, err
+	}
+	return latestQueriedDiff(result)
+}
+
+// localCommits returns the local commits recorded by arc for d.
+func (d *queriedDiff) localCommits() ([]uploadedCommit, error) {
+	if d == nil || !isObject(d.Properties) {
+		return nil, nil
+	}
+	var properties diffProperties
+	if err := json.Unmarshal(d.Properties, &properties); err != nil {
+		return nil, fmt.Errorf("failed to decode the properties of diff %s: %w", d.ID, err)
+	}
+	var commits []uploadedCommit
+	for hash, c := range properties.LocalCommits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		commits = append(commits, uploadedCommit{hash: cmp.Or(c.Commit, hash), subject: cmp.Or(c.Summary, subject)})
+	}
+	slices.SortFunc(commits, func(a, b uploadedCommit) int { return cmp.Compare(a.subject, b.subject) })
+	return commits, nil
+}
+
+// latestDiffCommits fetches the commits of the latest diff of the revision id.
+func latestDiffCommits(id string) ([]uploadedCommit, error) {
+	if isDevMode() {
+		return nil, nil
+	}
+	latest, err := queryLatestDiff(id)
+	if err != nil {
+		return nil, err
+	}
+	return latest.localCommits()
+}
+
+// updateMessage lists the subjects of the commits missing from uploaded,
+// matched by hash or by subject for the rebased ones.
+func updateMessage(commits []*object.Commit, uploaded []uploadedCommit) string {
+	var lines []string
+	for _, c := range commits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		if slices.ContainsFunc(uploaded, func(u uploadedCommit) bool {
+			return u.hash == c.Hash.String() || u.subject == subject
+		}) {
+			continue
+		}
+		lines = append(lines, "- "+subject)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// createMessage fills the create template with the subject of c as title
+// and its body, without trailers, as summary.
+func createMessage(c *object.Commit) string {
+	message, _ := splitTrailers(c.Message)
+	title, body, _ := strings.Cut(message, "\n")
+	return title + strings.Replace(createTemplate, "Summary: ", "Summary: "+strings.TrimSpace(body), 1)
+}
+
 type messagePanel struct {
 	*tui.TextPanel
 	msg *string
@@ -15,6 +139,13 @@ func (mp *messagePanel) Update(msg tui.InputMessage) (handled bool, redraw bool)
 	return handled, redraw
 }
 
+// SetText replaces the message and moves the cursor to its end.
+func (mp *messagePanel) SetText(text string) {
+	mp.Text = []rune(text)
+	mp.Cursor = len(mp.Text)
+	*mp.msg = text
+}
+
 func newMessagePanelUpdate(name string) messagePanel {
 	return messagePanel{
 		TextPanel: &tui.TextPanel{
@@ -29,7 +160,7 @@ func newMessagePanelUpdate(name string) messagePanel {
 }
 
 func newMessagePanelCreate(name string) messagePanel {
-	msg := "\n\nSummary: \n\nTest case: \n\nReviewers: \n\n"
+	msg := createTemplate
 	return messagePanel{
 		TextPanel: &tui.TextPanel{
 			PanelBase: tui.PanelBase{
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"app/tui"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffOptions holds the optional arc diff flags chosen in the options panel.
+type diffOptions struct {
+	draft       bool
+	planChanges bool
+	noLint      bool
+	noUnit      bool
+	browse      bool
+	excuse      string
+	reviewers   string
+}
+
+// args returns the arc flags for the options.
+func (o *diffOptions) args() []string {
+	var args []string
+	if o.draft {
+		args = append(args, "--draft")
+	}
+	if o.planChanges {
+		args = append(args, "--plan-changes")
+	}
+	if o.noLint {
+		args = append(args, "--nolint")
+	}
+	if o.noUnit {
+		args = append(args, "--nounit")
+	}
+	if o.browse {
+		args = append(args, "--browse")
+	}
+	if o.excuse != "" {
+		args = append(args, "--excuse", o.excuse)
+	}
+	if o.reviewers != "" {
+		args = append(args, "--reviewers", o.reviewers)
+	}
+	return args
+}
+
+func (o *diffOptions) String() string {
+	args := o.args()
+	if len(args) == 0 {
+		return "no options"
+	}
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			args[i] = fmt.Sprintf("%q", arg)
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// valueFlags are the flags followed by a value.
+var valueFlags = map[string]bool{"--excuse": true, "--reviewers": true}
+
+// splitFlags groups args by flag: each flag alone, or with its value for
+// the valueFlags, so that a value is never taken for a flag.
+func splitFlags(args []string) [][]string {
+	var flags [][]string
+	for i := 0; i < len(args); i++ {
+		end := i + 1
+		if valueFlags[args[i]] && end < len(args) {
+			end++
+		}
+		flags = append(flags, args[i:end])
+		i = end - 1
+	}
+	return flags
+}
+
+// hasFlag reports whether args hold the flag name, and its value if any.
+func hasFlag(args []string, name string) (value string, ok bool) {
+	for _, flag := range splitFlags(args) {
+		if flag[0] != name {
+			continue
+		}
+		if len(flag) > 1 {
+			value = flag[1]
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// mergeFlags appends to flags the extra flags not already present, by name.
+func mergeFlags(flags []string, extra ...string) []string {
+	for _, flag := range splitFlags(extra) {
+		if _, ok := hasFlag(flags, flag[0]); !ok {
+			flags = append(flags, flag...)
+		}
+	}
+	return flags
+}
+
+// option is an entry of the options panel, either a toggle or a text input.
+type option struct {
+	flag   string
+	toggle *bool
+	text   *string
+}
+
+func (o option) String() string {
+	if o.toggle != nil {
+		mark := " "
+		if *o.toggle {
+			mark = "x"
+		}
+		return fmt.Sprintf("[%s] %s", mark, o.flag)
+	}
+	return fmt.Sprintf("%s: %s", o.flag, *o.text)
+}
+
+type optionsPanel struct {
+	*tui.ListPanel[option]
+	options *diffOptions
+	editing bool
+}
+
+func (op *optionsPanel) Draw(_ bool) string {
+	var buffer bytes.Buffer
+	for i, item := range op.Items {
+		selected := ""
+		cursor := ""
+		if op.Selected == i {
+			selected = colorRed + "*" + colorReset
+			if op.editing {
+				cursor = "_"
+			}
+		}
+		buffer.WriteString(fmt.Sprintf("%s %s%s\n", selected, item.String(), cursor))
+	}
+	if op.editing {
+		buffer.WriteString("Enter: done")
+	} else {
+		buffer.WriteString("Space: toggle  •  Enter: edit")
+	}
+	return buffer.String()
+}
+
+func (op *optionsPanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
+	if len(op.Items) == 0 {
+		return false, false
+	}
+	item := op.Items[op.Selected]
+	if op.editing {
+		switch {
+		case msg.IsKey(tui.KeyEnter), msg.IsKey(tui.KeyEsc):
+			op.editing = false
+		case msg.IsKey(tui.KeyBackspace):
+			if text := []rune(*item.text); len(text) > 0 {
+				*item.text = string(text[:len(text)-1])
+			}
+		case msg.IsKey(tui.KeySpace), msg.IsChar(' '):
+			*item.text += " "
+		default:
+			r, ok := msg.Char()
+			if !ok {
+				return false, false
+			}
+			*item.text += string(r)
+		}
+		return true, true
+	}
+
+	switch {
+	case msg.IsChar(' '), msg.IsKey(tui.KeySpace), msg.IsKey(tui.KeyEnter):
+		if item.toggle != nil {
+			*item.toggle = !*item.toggle
+		} else {
+			op.editing = true
+		}
+		return true, true
+	}
+	return op.ListPanel.Update(msg)
+}
+
+func newOptionsPanel(name string) optionsPanel {
+	options := &diffOptions{}
+	return optionsPanel{
+		ListPanel: &tui.ListPanel[option]{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+			Items: []option{
+				{flag: "--draft", toggle: &options.draft},
+				{flag: "--plan-changes", toggle: &options.planChanges},
+				{flag: "--nolint", toggle: &options.noLint},
+				{flag: "--nounit", toggle: &options.noUnit},
+				{flag: "--browse", toggle: &options.browse},
+				{flag: "--excuse", text: &options.excuse},
+				{flag: "--reviewers", text: &options.reviewers},
+			},
+		},
+		options: options,
+	}
+}
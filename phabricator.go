@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const phabricatorName = "phabricator"
+
+// phabricatorBackend sends the revisions to Phabricator through arc.
+type phabricatorBackend struct{}
+
+func (phabricatorBackend) Name() string {
+	return phabricatorName
+}
+
+func (phabricatorBackend) List() ([]diff, error) {
+	if isDevMode() {
+		return mockDiffs(), nil
+	}
+	cmd := exec.Command("arc", "list")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run 'arc list' command: %w", err)
+	}
+	lines := strings.Split(string(output), "\n")
+	var diffs []diff
+	for _, line := range lines {
+		if d, ok := parseDiff(line); ok {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, nil
+}
+
+// Create gives the message to arc through a temporary file, as arc would
+// otherwise open an editor.
+func (phabricatorBackend) Create(from, on, message string, flags []string) ([]byte, error) {
+	file, err := os.CreateTemp("", "bow-create-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message file: %w", err)
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	if _, err := file.WriteString(message); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write message file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write message file: %w", err)
+	}
+	args := append([]string{"diff", from, "--head", on, "--create"}, flags...)
+	return runArc(append(args, "--message-file", file.Name())...)
+}
+
+func (phabricatorBackend) Update(id, from, on, message string, flags []string) ([]byte, error) {
+	args := []string{"diff", from, "--head", on, "--update", id, "--message", message}
+	return runArc(append(args, flags...)...)
+}
+
+func (phabricatorBackend) Land(id string) ([]byte, error) {
+	return runArc("land", "--revision", id)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"app/tui"
+)
+
+// promptPanel asks for a line of text, submitted with Enter. Esc submits
+// an empty text to cancel.
+type promptPanel struct {
+	*tui.TextPanel
+	onSubmit func(text string)
+}
+
+func (pp *promptPanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
+	if msg.IsKey(tui.KeyEnter) {
+		if pp.onSubmit != nil {
+			pp.onSubmit(string(pp.Text))
+		}
+		return true, true
+	}
+	if msg.IsKey(tui.KeyEsc) {
+		if pp.onSubmit != nil {
+			pp.onSubmit("")
+		}
+		return true, true
+	}
+	return pp.TextPanel.Update(msg)
+}
+
+// ask shows title with the initial text, and calls onSubmit with the submitted text.
+func (pp *promptPanel) ask(title, initial string, onSubmit func(text string)) {
+	pp.Title = title
+	pp.Text = []rune(initial)
+	pp.Cursor = len(pp.Text)
+	pp.onSubmit = onSubmit
+}
+
+func newPromptPanel() promptPanel {
+	return promptPanel{
+		TextPanel: &tui.TextPanel{
+			PanelBase: tui.PanelBase{
+				Border: true,
+			},
+		},
+	}
+}
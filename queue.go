@@ -0,0 +1,151 @@
+package main
+
+import (
+	"app/tui"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// queuePath is the file of the updates waiting to be sent, like the ones
+// queued by a restack.
+func queuePath() string {
+	return filepath.Join(cacheDir(), "queue.jsonl")
+}
+
+func readQueue() ([]operation, error) {
+	return readOperations(queuePath())
+}
+
+// writeQueue replaces the queued operations, removing the file when there is none.
+func writeQueue(ops []operation) error {
+	if len(ops) == 0 {
+		if err := os.Remove(queuePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear queue: %w", err)
+		}
+		return nil
+	}
+	var content strings.Builder
+	for _, op := range ops {
+		line, err := json.Marshal(op)
+		if err != nil {
+			return fmt.Errorf("failed to encode operation: %w", err)
+		}
+		content.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(queuePath(), []byte(content.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write queue: %w", err)
+	}
+	return nil
+}
+
+// runQueue runs the queued operations in order, recording them in the
+// history and writing their output to out. It stops at the first failure,
+// which stays queued with the operations after it.
+func runQueue(backend ReviewBackend, out io.Writer) error {
+	ops, err := readQueue()
+	if err != nil {
+		return err
+	}
+	for i := range ops {
+		op := &ops[i]
+		_, _ = fmt.Fprintf(out, "Updating %s to %s\n", op.Revision, short(op.On))
+		output, runErr := op.run(backend)
+		_, _ = out.Write(output)
+		if err := recordOperation(op); err != nil {
+			return err
+		}
+		if runErr != nil {
+			if err := writeQueue(ops[i:]); err != nil {
+				return err
+			}
+			return fmt.Errorf("failed to update %s, %d updates left in the queue: %w", op.Revision, len(ops)-i, runErr)
+		}
+	}
+	return writeQueue(nil)
+}
+
+const queueUsage = `usage: bow queue [list]
+       bow queue run
+       bow queue clear
+`
+
+// runQueueCommand implements the queue subcommand and returns the exit code.
+func runQueueCommand(args []string, out io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprint(os.Stderr, queueUsage)
+		return 2
+	}
+	var err error
+	switch {
+	case len(args) == 0 || args[0] == "list":
+		var ops []operation
+		ops, err = readQueue()
+		for i, op := range ops {
+			_, _ = fmt.Fprintf(out, "%4d  update %s to %s..%s: %s\n", i+1, op.Revision, short(op.From), short(op.On), op.Message)
+		}
+	case args[0] == "run":
+		var cfg config
+		var backend ReviewBackend
+		cfg, err = loadConfig()
+		if err == nil {
+			backend, err = newBackend(cfg)
+		}
+		if err == nil {
+			err = runQueue(backend, out)
+		}
+	case args[0] == "clear":
+		err = writeQueue(nil)
+	default:
+		fmt.Fprint(os.Stderr, queueUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// queuePanel lists the queued updates.
+type queuePanel struct {
+	*tui.InfoPanel
+	onRun   func()
+	onClose func()
+}
+
+func (qp *queuePanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
+	switch {
+	case msg.IsKey(tui.KeyEnter) && qp.onRun != nil:
+		qp.onRun()
+	case msg.IsKey(tui.KeyEsc) && qp.onClose != nil:
+		qp.onClose()
+	default:
+		return qp.InfoPanel.Update(msg)
+	}
+	return true, true
+}
+
+// setQueue lists ops, the result of the restack of branch.
+func (qp *queuePanel) setQueue(branch string, ops []operation) {
+	lines := []string{fmt.Sprintf("Restacked %s, %d revisions to update:", branch, len(ops)), ""}
+	for _, op := range ops {
+		lines = append(lines, fmt.Sprintf("  %s%s%s → %s", colorYellow, op.Revision, colorReset, short(op.On)))
+	}
+	qp.Lines = append(lines, "", "Enter: send the updates  •  Esc: later, with bow queue run")
+}
+
+func newQueuePanel(name string) queuePanel {
+	return queuePanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
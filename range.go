@@ -0,0 +1,142 @@
+package main
+
+import (
+	"app/tui"
+	"bytes"
+	"fmt"
+)
+
+// rangePanel selects the diffFrom..diffOn range in a single commit list: mark
+// one end, then move to the other one.
+type rangePanel struct {
+	*tui.ListPanel[commit]
+	from   *commit
+	on     *commit
+	cursor *commit
+	// anchor is the index of the marked end, -1 when nothing is marked.
+	anchor int
+	graph  []string
+	// revisions are the listed revisions, to annotate the commits with their status.
+	revisions *[]diff
+	// counted caches the number of commits of the range, shared with the status bar.
+	counted *rangeCount
+}
+
+// bounds returns the indexes of the newest and oldest commits of the range.
+func (rp *rangePanel) bounds() (newest, oldest int, ok bool) {
+	if rp.anchor < 0 || rp.anchor >= len(rp.Items) {
+		return 0, 0, false
+	}
+	return min(rp.anchor, rp.Selected), max(rp.anchor, rp.Selected), true
+}
+
+func (rp *rangePanel) Draw(_ bool) string {
+	var buffer bytes.Buffer
+	newest, oldest, marked := rp.bounds()
+	var revisions []diff
+	if rp.revisions != nil {
+		revisions = *rp.revisions
+	}
+	for i, item := range rp.Items {
+		selected := " "
+		if rp.Selected == i {
+			selected = colorRed + "*" + colorReset
+		}
+		end := "  "
+		switch {
+		case marked && i == newest && i == oldest:
+			end = colorGreen + "[]" + colorReset
+		case marked && i == newest:
+			end = colorGreen + "┬ " + colorReset
+		case marked && i == oldest:
+			end = colorGreen + "┴ " + colorReset
+		case marked && i > newest && i < oldest:
+			end = colorGreen + "│ " + colorReset
+		}
+		graph := ""
+		if i < len(rp.graph) {
+			graph = colorCyan + rp.graph[i] + colorReset + " "
+		}
+		buffer.WriteString(fmt.Sprintf("%s%s%s%s %s\n", selected, end, graph, revisionLabel(item, revisions), item.String()))
+	}
+	if marked {
+		count := "no range"
+		if rp.from.Commit != nil && rp.on.Commit != nil {
+			n, err := rp.counted.of(rp.from.Commit, rp.on.Commit)
+			count = fmt.Sprintf("%d commits", n)
+			if err != nil {
+				count = colorYellow + err.Error() + colorReset
+			}
+		}
+		buffer.WriteString(fmt.Sprintf("\n%s  •  m: move the mark, on the mark to clear it", count))
+	} else {
+		buffer.WriteString("\nm: mark one end of the range")
+	}
+	return buffer.String()
+}
+
+func (rp *rangePanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
+	switch {
+	case msg.IsChar('m') && rp.anchor == rp.Selected:
+		rp.anchor = -1
+		handled, redraw = true, true
+	case msg.IsChar('m'):
+		rp.anchor = rp.Selected
+		handled, redraw = true, true
+	default:
+		handled, redraw = rp.ListPanel.Update(msg)
+	}
+	rp.sync()
+	return handled, redraw
+}
+
+// sync writes the cursor and the range ends to the shared commit pointers.
+func (rp *rangePanel) sync() {
+	if len(rp.Items) == 0 || rp.Selected < 0 || rp.Selected >= len(rp.Items) {
+		return
+	}
+	*rp.cursor = rp.Items[rp.Selected]
+	if newest, oldest, ok := rp.bounds(); ok {
+		*rp.on = rp.Items[newest]
+		*rp.from = rp.Items[oldest]
+	}
+}
+
+// setItems replaces the commits, keeping the range ends when they are still listed.
+func (rp *rangePanel) setItems(commits []commit) {
+	rp.Items = commits
+	rp.graph = graphColumns(commits)
+	rp.selectRange()
+}
+
+// selectRange places the mark on diffFrom and the cursor on diffOn.
+func (rp *rangePanel) selectRange() {
+	rp.anchor, rp.Selected = -1, 0
+	for i, c := range rp.Items {
+		if rp.from.Commit != nil && c.Hash == rp.from.Hash {
+			rp.anchor = i
+		}
+		if rp.on.Commit != nil && c.Hash == rp.on.Hash {
+			rp.Selected = i
+		}
+	}
+	rp.sync()
+}
+
+func newRangePanel(name string, commits []commit, from, on *commit) rangePanel {
+	return rangePanel{
+		ListPanel: &tui.ListPanel[commit]{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+			Items: commits,
+		},
+		from:    from,
+		on:      on,
+		cursor:  &commit{},
+		anchor:  -1,
+		graph:   graphColumns(commits),
+		counted: &rangeCount{},
+	}
+}
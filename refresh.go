@@ -0,0 +1,244 @@
+package main
+
+import (
+	"app/tui"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// highlightDuration is how long a revision whose status changed stays marked.
+const highlightDuration = 5 * time.Second
+
+// refresh reloads the commits and the revisions concurrently in the
+// background, applying each on the main loop as soon as it is loaded. It
+// does nothing if a refresh is already running.
+func (h *handler) refresh(app *tui.App) {
+	h.reload(app, h.firstParent)
+}
+
+// reload is refresh listing only the first parent chain of HEAD when
+// firstParent is set. It reports whether the reload started.
+func (h *handler) reload(app *tui.App, firstParent bool) bool {
+	if !h.refreshing.CompareAndSwap(false, true) {
+		return false
+	}
+	backend := h.backend
+	var rangeOf string
+	if h.launch != nil {
+		rangeOf = h.launch.rangeOf
+	}
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		commits, err := getCommits(firstParent)
+		var from, on *object.Commit
+		var rangeErr error
+		if rangeOf != "" {
+			from, on, rangeErr = revisionRange(rangeOf)
+		}
+		app.Post(func() {
+			if rangeOf != "" && h.launch != nil {
+				h.launch.rangeOf = ""
+				h.launch.from, h.launch.on, h.launch.rangeErr = from, on, rangeErr
+			}
+			h.applyCommits(commits, err)
+		})
+	})
+	wg.Go(func() {
+		diffs, err := backend.List()
+		if err == nil {
+			if err := writeRevisionCache(backend.Name(), diffs); err != nil {
+				slog.Warn("failed to cache revisions", "error", err)
+			}
+		}
+		app.Post(func() { h.applyDiffs(app, diffs, err) })
+	})
+	go func() {
+		wg.Wait()
+		h.refreshing.Store(false)
+	}()
+	return true
+}
+
+func (h *handler) applyCommits(commits []commit, err error) {
+	for _, panel := range []*commitPanel{&h.panels.diffFrom, &h.panels.diffOn} {
+		panel.loading, panel.loadErr = false, err
+	}
+	if err != nil {
+		slog.Error("failed to load commits", "error", err)
+		return
+	}
+	h.panels.diffFrom.setItems(commits)
+	h.panels.diffOn.setItems(commits)
+	h.panels.rangeSel.setItems(commits)
+	slog.Debug("loaded commits", "commits", len(commits))
+	h.applyLaunchRange()
+}
+
+func (h *handler) applyDiffs(app *tui.App, diffs []diff, err error) {
+	h.panels.diffs.loading, h.panels.diffs.loadErr = false, err
+	if err != nil {
+		slog.Error("failed to load revisions", "error", err)
+		return
+	}
+	// Revisions may have been updated since their diffs were fetched
+	h.uploaded = nil
+	h.panels.interdiff.forget()
+	h.panels.comments.forget()
+	h.panels.stack.forget()
+	changed := h.panels.diffs.setItems(diffs)
+	slog.Debug("loaded revisions", "revisions", len(diffs), "changed", changed)
+	h.applyLaunchRevision()
+	if len(changed) > 0 {
+		// Redraw once the highlight expired
+		time.AfterFunc(highlightDuration, func() { app.Post(func() {}) })
+	}
+}
+
+// applyLaunchRange selects the range bow was launched on in the loaded commits.
+func (h *handler) applyLaunchRange() {
+	if h.launch == nil {
+		return
+	}
+	target := h.launch
+	switch {
+	case target.rangeErr != nil:
+		slog.Warn("failed to derive the range of the revision", "revision", target.revision, "error", target.rangeErr)
+		h.notify(colorYellow + "Select the range yourself: " + target.rangeErr.Error() + colorReset)
+	case target.on != nil:
+		from, on := commit{target.from}, commit{target.on}
+		if !h.panels.diffFrom.selectCommit(from) || !h.panels.diffOn.selectCommit(on) {
+			slog.Warn("launch range not listed", "from", from.Hash, "on", on.Hash)
+			h.notify(fmt.Sprintf("%s%s..%s is not among the listed commits%s", colorYellow, short(from.Hash.String()), short(on.Hash.String()), colorReset))
+		}
+	}
+	target.from, target.on, target.rangeErr = nil, nil, nil
+	h.doneLaunch()
+}
+
+// applyLaunchRevision selects the revision bow was launched on in the loaded revisions.
+func (h *handler) applyLaunchRevision() {
+	if h.launch == nil || h.launch.revision == "" {
+		return
+	}
+	id := h.launch.revision
+	h.activeCommand = Update
+	h.layoutRight()
+	if !h.panels.diffs.selectRevision(id) {
+		slog.Warn("launch revision not listed", "revision", id)
+		h.notify(colorYellow + id + " is not among your revisions" + colorReset)
+	}
+	h.launch.revision = ""
+	h.doneLaunch()
+}
+
+// doneLaunch forgets the launch target once entirely applied.
+func (h *handler) doneLaunch() {
+	if t := h.launch; t.revision == "" && t.rangeOf == "" && t.on == nil && t.rangeErr == nil {
+		h.launch = nil
+	}
+}
+
+// placeholder describes a panel whose items are still loading or failed to load.
+func placeholder(what string, loading bool, err error) (string, bool) {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%sFailed to load %s: %v%s", colorRed, what, err, colorReset), true
+	case loading:
+		return fmt.Sprintf("Loading %s…", what), true
+	}
+	return "", false
+}
+
+// revisionCachePath is the cache of the revisions last listed by backend
+// for the current repository.
+func revisionCachePath(backend string) (string, error) {
+	root, err := repoRoot()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root + "\x00" + backend))
+	return filepath.Join(cacheDir(), "revisions", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+type cachedRevision struct {
+	// Status is the name of the status, like "Needs Review".
+	Status  string `json:"status"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// readRevisionCache returns the cached revisions, none when there is no cache.
+func readRevisionCache(backend string) ([]diff, error) {
+	path, err := revisionCachePath(backend)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision cache: %w", err)
+	}
+	var cached []cachedRevision
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return nil, fmt.Errorf("failed to decode revision cache: %w", err)
+	}
+	var diffs []diff
+	for _, r := range cached {
+		s, ok := stringToStatus[r.Status]
+		if !ok {
+			continue
+		}
+		diffs = append(diffs, diff{status: s, id: r.ID, message: r.Message})
+	}
+	return diffs, nil
+}
+
+func writeRevisionCache(backend string, diffs []diff) error {
+	path, err := revisionCachePath(backend)
+	if err != nil {
+		return err
+	}
+	cached := []cachedRevision{}
+	for _, d := range diffs {
+		for name, s := range stringToStatus {
+			if s == d.status {
+				cached = append(cached, cachedRevision{Status: name, ID: d.id, Message: d.message})
+			}
+		}
+	}
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return fmt.Errorf("failed to encode revision cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write revision cache: %w", err)
+	}
+	return nil
+}
+
+// poll refreshes every interval until the process exits. The refreshes
+// start on the main loop, which owns the handler state.
+func (h *handler) poll(app *tui.App, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			app.Post(func() { h.refresh(app) })
+		}
+	}()
+}
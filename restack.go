@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/storer"
+)
+
+// restackState is saved while a restack is in progress, to queue the
+// updates once a rebase stopped on conflicts is continued.
+type restackState struct {
+	Amended  string `json:"amended"`
+	Replaced string `json:"replaced"`
+	Branch   string `json:"branch"`
+}
+
+func restackStatePath() string {
+	return filepath.Join(cacheDir(), "restack.json")
+}
+
+// revisionOf returns the revision of the "Differential Revision" trailer of message, like D123.
+func revisionOf(message string) string {
+	_, trailers := splitTrailers(message)
+	for _, t := range trailers {
+		if t.key == "Differential Revision" {
+			return path.Base(strings.TrimRight(t.value, "/"))
+		}
+	}
+	return ""
+}
+
+// patchID returns the stable patch id of the change of the commit hash, empty
+// when the commit changes nothing.
+func patchID(hash string) (string, error) {
+	patch, err := runGit("show", "--format=", "--no-color", "--no-ext-diff", hash)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command("git", "patch-id", "--stable")
+	cmd.Stdin = bytes.NewReader(patch)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the patch id of %s: %w", short(hash), err)
+	}
+	id, _, _ := strings.Cut(string(output), " ")
+	return strings.TrimSpace(id), nil
+}
+
+// changeMatcher reports whether a commit is a version of amended: linked to
+// the same revision, or when amended has no revision, with exactly the same
+// change, as told by their patch ids.
+type changeMatcher struct {
+	revision string
+	patchID  string
+}
+
+func newChangeMatcher(amended *object.Commit) (changeMatcher, error) {
+	if revision := revisionOf(amended.Message); revision != "" {
+		return changeMatcher{revision: revision}, nil
+	}
+	id, err := patchID(amended.Hash.String())
+	if err != nil {
+		return changeMatcher{}, err
+	}
+	if id == "" {
+		return changeMatcher{}, fmt.Errorf("%s has no revision and changes nothing to find its previous version by", short(amended.Hash.String()))
+	}
+	return changeMatcher{patchID: id}, nil
+}
+
+func (m changeMatcher) matches(c *object.Commit) (bool, error) {
+	if m.revision != "" {
+		return revisionOf(c.Message) == m.revision, nil
+	}
+	id, err := patchID(c.Hash.String())
+	return id == m.patchID, err
+}
+
+// findReplaced returns the local branch holding a commit replaced by
+// amended, and that commit. Branches already built on amended are skipped.
+// When several branches hold it, the branch containing all the others is
+// the one to restack, and it is an error when there is none, or when the
+// branches hold different versions of the change.
+func findReplaced(repo *git.Repository, amended *object.Commit) (string, *object.Commit, error) {
+	matcher, err := newChangeMatcher(amended)
+	if err != nil {
+		return "", nil, err
+	}
+	iter, err := repo.Branches()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	var branches []*plumbing.Reference
+	_ = iter.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref)
+		return nil
+	})
+	slices.SortFunc(branches, func(a, b *plumbing.Reference) int {
+		return cmp.Compare(a.Name().Short(), b.Name().Short())
+	})
+
+	var found []*plumbing.Reference
+	var replaced *object.Commit
+	for _, ref := range branches {
+		tip, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get commit of %s: %w", ref.Name().Short(), err)
+		}
+		var match *object.Commit
+		walked := 0
+		err = object.NewCommitPreorderIter(tip, nil, nil).ForEach(func(c *object.Commit) error {
+			walked++
+			if c.Hash == amended.Hash || walked > maxRangeWalk {
+				return storer.ErrStop
+			}
+			ok, err := matcher.matches(c)
+			if err != nil {
+				return err
+			}
+			if ok {
+				match = c
+				return storer.ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to walk %s: %w", ref.Name().Short(), err)
+		}
+		if match == nil {
+			continue
+		}
+		if replaced != nil && match.Hash != replaced.Hash {
+			return "", nil, fmt.Errorf("%s and %s hold different versions of %s, restack them with 'git rebase --onto'",
+				found[0].Name().Short(), ref.Name().Short(), short(amended.Hash.String()))
+		}
+		replaced = match
+		found = append(found, ref)
+	}
+	if replaced == nil {
+		return "", nil, fmt.Errorf("no branch holds a commit replaced by %s", short(amended.Hash.String()))
+	}
+	for _, ref := range found {
+		contains := true
+		for _, other := range found {
+			if _, err := runGit("merge-base", "--is-ancestor", other.Hash().String(), ref.Hash().String()); err != nil {
+				contains = false
+				break
+			}
+		}
+		if contains {
+			return ref.Name().Short(), replaced, nil
+		}
+	}
+	var names []string
+	for _, ref := range found {
+		names = append(names, ref.Name().Short())
+	}
+	return "", nil, fmt.Errorf("the branches %s all hold the commit replaced by %s, restack them with 'git rebase --onto'",
+		strings.Join(names, ", "), short(amended.Hash.String()))
+}
+
+// restackConflict is returned when the rebase of a restack stopped on conflicts.
+type restackConflict struct {
+	branch string
+	files  []string
+}
+
+func (e *restackConflict) Error() string {
+	return fmt.Sprintf("the rebase of %s stopped on conflicts in %s.\n"+
+		"Resolve them and run 'git rebase --continue', then 'bow restack --continue' to queue the updates.\n"+
+		"Or run 'git rebase --abort' to leave %s as it was.",
+		e.branch, strings.Join(e.files, ", "), e.branch)
+}
+
+// rebaseInProgress reports whether a rebase stopped in the repository.
+func rebaseInProgress() bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		output, err := runGit("rev-parse", "--git-path", dir)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(strings.TrimSpace(string(output))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// restack rebases the commits of the branch above the commit replaced by
+// amended onto amended, then queues the updates of their revisions.
+func restack(amended string) (string, []operation, error) {
+	files, err := dirtyFiles()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(files) > 0 {
+		return "", nil, errors.New("commit or stash the uncommitted changes before restacking")
+	}
+	repo, err := openRepo()
+	if err != nil {
+		return "", nil, err
+	}
+	amendedCommit, err := resolveCommit(repo, amended)
+	if err != nil {
+		return "", nil, err
+	}
+	branch, replaced, err := findReplaced(repo, amendedCommit)
+	if err != nil {
+		return "", nil, err
+	}
+
+	state := restackState{Amended: amendedCommit.Hash.String(), Replaced: replaced.Hash.String(), Branch: branch}
+	content, err := json.Marshal(state)
+	if err != nil {
+		return branch, nil, fmt.Errorf("failed to encode restack state: %w", err)
+	}
+	if err := os.WriteFile(restackStatePath(), content, 0600); err != nil {
+		return branch, nil, fmt.Errorf("failed to save restack state: %w", err)
+	}
+	if _, err := runGit("rebase", "--onto", state.Amended, state.Replaced, branch); err != nil {
+		if rebaseInProgress() {
+			output, _ := runGit("diff", "--name-only", "--diff-filter=U")
+			return branch, nil, &restackConflict{branch: branch, files: strings.Fields(string(output))}
+		}
+		_ = os.Remove(restackStatePath())
+		return branch, nil, err
+	}
+	ops, err := finishRestack(state)
+	return branch, ops, err
+}
+
+// finishRestack queues an update for each revision of the commits rebased
+// onto the amended commit.
+func finishRestack(state restackState) ([]operation, error) {
+	if rebaseInProgress() {
+		return nil, errors.New("a rebase is still in progress, finish it with 'git rebase --continue'")
+	}
+	repo, err := openRepo()
+	if err != nil {
+		return nil, err
+	}
+	amended, err := resolveCommit(repo, state.Amended)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := resolveCommit(repo, state.Branch)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := rangeCommits(amended, tip)
+	if err != nil {
+		return nil, err
+	}
+	root, err := repoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	note := "Restacked on " + cmp.Or(revisionOf(amended.Message), short(state.Amended))
+	var ops []operation
+	for _, c := range commits {
+		revision := revisionOf(c.Message)
+		if revision == "" || c.NumParents() == 0 {
+			continue
+		}
+		ops = append(ops, operation{
+			Command:  Update,
+			From:     c.ParentHashes[0].String(),
+			On:       c.Hash.String(),
+			Revision: revision,
+			Message:  note,
+			Root:     root,
+		})
+	}
+	queue, err := readQueue()
+	if err != nil {
+		return nil, err
+	}
+	// The new updates replace the ones of a previous restack not sent yet
+	queue = slices.DeleteFunc(queue, func(queued operation) bool {
+		return slices.ContainsFunc(ops, func(op operation) bool { return op.Revision == queued.Revision })
+	})
+	if err := writeQueue(append(queue, ops...)); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(restackStatePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove restack state: %w", err)
+	}
+	return ops, nil
+}
+
+// runRestack implements the restack subcommand and returns the exit code.
+func runRestack(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("restack", flag.ContinueOnError)
+	resume := flags.Bool("continue", false, "queue the updates once a rebase stopped on conflicts is continued")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: bow restack [<amended commit>]\n       bow restack -continue")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return 2
+	}
+
+	var ops []operation
+	var err error
+	if *resume {
+		var content []byte
+		content, err = os.ReadFile(restackStatePath())
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "no restack in progress")
+			return 1
+		}
+		var state restackState
+		if err == nil {
+			err = json.Unmarshal(content, &state)
+		}
+		if err == nil {
+			ops, err = finishRestack(state)
+		}
+	} else {
+		var branch string
+		branch, ops, err = restack(cmp.Or(flags.Arg(0), "HEAD"))
+		if err == nil {
+			_, _ = fmt.Fprintf(out, "Restacked %s\n", branch)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, op := range ops {
+		_, _ = fmt.Fprintf(out, "Queued the update of %s to %s\n", op.Revision, short(op.On))
+	}
+	if len(ops) > 0 {
+		_, _ = fmt.Fprintln(out, "Run 'bow queue run' to send them")
+	}
+	return 0
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"app/tui"
+	"cmp"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	gitdiff "github.com/go-git/go-git/v6/plumbing/format/diff"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+type forbiddenPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+type scanConfig struct {
+	// Forbidden are regular expressions no added line may match.
+	Forbidden []forbiddenPattern `json:"forbidden"`
+	// MaxFileSize is the size in bytes above which a changed file is
+	// reported, defaultMaxFileSize when 0.
+	MaxFileSize int64 `json:"max_file_size"`
+	// Skip are the path patterns of the files not scanned, matched against
+	// the path and the base name, defaultScanSkip when nil.
+	Skip []string `json:"skip"`
+}
+
+const defaultMaxFileSize = 1 << 20
+
+// defaultScanSkip are lock files, full of checksums looking like tokens.
+var defaultScanSkip = []string{"go.sum", "*.lock", "package-lock.json"}
+
+// secretRule finds a kind of credential in a line.
+type secretRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var secretRules = []secretRule{
+	{"private key", regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws.{0,20}(secret|key).{0,20}['"=:]\s*['"]?[A-Za-z0-9/+]{40}\b`)},
+}
+
+// tokenRe matches the candidates of the high-entropy token rule.
+var tokenRe = regexp.MustCompile(`[A-Za-z0-9+/_\-]{32,}={0,2}`)
+
+// minTokenEntropy is the entropy in bits per character above which a token
+// is taken for a secret. Random tokens of 32 characters or more are close to
+// 5, identifiers, paths and hex hashes stay around 4.
+const minTokenEntropy = 4.5
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	total := float64(len([]rune(s)))
+	var bits float64
+	for _, n := range counts {
+		p := float64(n) / total
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// scanFinding is content that should not be uploaded, at line of path, or
+// in the whole file when line is 0.
+type scanFinding struct {
+	path string
+	line int
+	rule string
+}
+
+func (f scanFinding) String() string {
+	if f.line == 0 {
+		return fmt.Sprintf("%s: %s", f.path, f.rule)
+	}
+	return fmt.Sprintf("%s:%d: %s", f.path, f.line, f.rule)
+}
+
+// scanner checks the added lines against the secret rules and the forbidden patterns.
+type scanner struct {
+	forbidden []secretRule
+	maxSize   int64
+	skip      []string
+}
+
+func newScanner(cfg scanConfig) (*scanner, error) {
+	s := &scanner{maxSize: cmp.Or(cfg.MaxFileSize, defaultMaxFileSize), skip: cfg.Skip}
+	if s.skip == nil {
+		s.skip = defaultScanSkip
+	}
+	for _, f := range cfg.Forbidden {
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid forbidden pattern %q: %w", f.Name, err)
+		}
+		s.forbidden = append(s.forbidden, secretRule{name: cmp.Or(f.Name, f.Pattern), re: re})
+	}
+	return s, nil
+}
+
+func (s *scanner) skipped(file string) bool {
+	for _, pattern := range s.skip {
+		if ok, _ := path.Match(pattern, file); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scanLine returns the rules matched by an added line.
+func (s *scanner) scanLine(line string) []string {
+	var rules []string
+	for _, rule := range secretRules {
+		if rule.re.MatchString(line) {
+			rules = append(rules, rule.name)
+		}
+	}
+	for _, token := range tokenRe.FindAllString(line, -1) {
+		if entropy(token) > minTokenEntropy {
+			rules = append(rules, "high-entropy token")
+			break
+		}
+	}
+	for _, rule := range s.forbidden {
+		if rule.re.MatchString(line) {
+			rules = append(rules, "forbidden: "+rule.name)
+		}
+	}
+	return rules
+}
+
+// scanPatch scans the files of patch changed in on: their size, whether
+// they are binary, and their added lines.
+func (s *scanner) scanPatch(patch *object.Patch, on *object.Commit) []scanFinding {
+	var findings []scanFinding
+	for _, fp := range patch.FilePatches() {
+		_, to := fp.Files()
+		if to == nil || s.skipped(to.Path()) {
+			continue
+		}
+		name := to.Path()
+		if file, err := on.File(name); err == nil && file.Size > s.maxSize {
+			findings = append(findings, scanFinding{path: name, rule: fmt.Sprintf("%d bytes, over the limit of %d", file.Size, s.maxSize)})
+		}
+		if fp.IsBinary() {
+			findings = append(findings, scanFinding{path: name, rule: "binary file"})
+			continue
+		}
+		line := 1
+		for _, chunk := range fp.Chunks() {
+			lines := strings.SplitAfter(chunk.Content(), "\n")
+			if lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			switch chunk.Type() {
+			case gitdiff.Equal:
+				line += len(lines)
+			case gitdiff.Add:
+				for _, l := range lines {
+					for _, rule := range s.scanLine(l) {
+						findings = append(findings, scanFinding{path: name, line: line, rule: rule})
+					}
+					line++
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b scanFinding) int {
+		return cmp.Or(cmp.Compare(a.path, b.path), cmp.Compare(a.line, b.line))
+	})
+	return findings
+}
+
+// scanRun holds the findings of the scan of a from..on range.
+type scanRun struct {
+	from, on   string
+	findings   []scanFinding
+	overridden bool
+}
+
+// scanRange scans the changes of the from..on range before their upload.
+func scanRange(from, on commit, cfg scanConfig) (*scanRun, error) {
+	s, err := newScanner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := rangePatch(from.Commit, on.Commit)
+	if err != nil {
+		return nil, err
+	}
+	return &scanRun{
+		from:     from.Hash.String(),
+		on:       on.Hash.String(),
+		findings: s.scanPatch(patch, on.Commit),
+	}, nil
+}
+
+type scanPanel struct {
+	*tui.InfoPanel
+}
+
+// setRun shows the findings of run.
+func (sp *scanPanel) setRun(run *scanRun) {
+	lines := []string{fmt.Sprintf("%s%d findings in %s..%s, the submission is blocked:%s", colorRed, len(run.findings), short(run.from), short(run.on), colorReset), ""}
+	for _, f := range run.findings {
+		lines = append(lines, "  "+f.String())
+	}
+	sp.Lines = append(lines, "", "Remove them from the commits, or !: submit anyway")
+}
+
+func newScanPanel(name string) scanPanel {
+	return scanPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
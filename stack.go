@@ -0,0 +1,286 @@
+package main
+
+import (
+	"app/tui"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxStackSize bounds the number of revisions fetched for a stack.
+const maxStackSize = 50
+
+// stackNode is a revision of a stack.
+type stackNode struct {
+	id     int
+	phid   string
+	title  string
+	status string
+}
+
+// stackEdge links a revision to a revision it depends on.
+type stackEdge struct {
+	child  string
+	parent string
+}
+
+type edgeSearchResult struct {
+	Data []struct {
+		SourcePHID      string `json:"sourcePHID"`
+		EdgeType        string `json:"edgeType"`
+		DestinationPHID string `json:"destinationPHID"`
+	} `json:"data"`
+	Cursor searchCursor `json:"cursor"`
+}
+
+// parseEdges converts an edge.search result to child to parent edges.
+func parseEdges(result edgeSearchResult) []stackEdge {
+	var edges []stackEdge
+	for _, e := range result.Data {
+		switch e.EdgeType {
+		case "revision.parent":
+			edges = append(edges, stackEdge{child: e.SourcePHID, parent: e.DestinationPHID})
+		case "revision.child":
+			edges = append(edges, stackEdge{child: e.DestinationPHID, parent: e.SourcePHID})
+		}
+	}
+	return edges
+}
+
+// searchRevisions fetches the revisions matching constraints, following the pages.
+func searchRevisions(constraints map[string]any) ([]stackNode, error) {
+	var nodes []stackNode
+	params := map[string]any{"constraints": constraints}
+	for {
+		var page revisionSearchResult
+		if err := callConduit("differential.revision.search", params, &page); err != nil {
+			return nil, err
+		}
+		for _, rev := range page.Data {
+			nodes = append(nodes, stackNode{id: rev.ID, phid: rev.PHID, title: rev.Fields.Title, status: rev.Fields.Status.Name})
+		}
+		if page.Cursor.After == nil {
+			return nodes, nil
+		}
+		params["after"] = *page.Cursor.After
+	}
+}
+
+// getStack fetches the revisions reachable from the revision id through
+// parent and child edges, and the edges between them.
+func getStack(id string) ([]stackNode, []stackEdge, error) {
+	if isDevMode() {
+		return []stackNode{
+			{id: 1, phid: "PHID-1", title: "Add the storage layer", status: "Accepted"},
+			{id: 2, phid: "PHID-2", title: "Add the cache", status: "Needs Review"},
+			{id: 3, phid: "PHID-3", title: "Use the cache", status: "Draft"},
+		}, []stackEdge{
+			{child: "PHID-2", parent: "PHID-1"},
+			{child: "PHID-3", parent: "PHID-2"},
+		}, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "D"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid revision %q", id)
+	}
+	start, err := searchRevisions(map[string]any{"ids": []int{n}})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(start) == 0 {
+		return nil, nil, fmt.Errorf("revision %s not found", id)
+	}
+
+	seen := map[string]bool{start[0].phid: true}
+	frontier := []string{start[0].phid}
+	var edges []stackEdge
+	for len(frontier) > 0 && len(seen) < maxStackSize {
+		var next []string
+		params := map[string]any{
+			"sourcePHIDs": frontier,
+			"types":       []string{"revision.parent", "revision.child"},
+		}
+		for {
+			var page edgeSearchResult
+			if err := callConduit("edge.search", params, &page); err != nil {
+				return nil, nil, err
+			}
+			for _, e := range parseEdges(page) {
+				if !slices.Contains(edges, e) {
+					edges = append(edges, e)
+				}
+				for _, phid := range []string{e.child, e.parent} {
+					if !seen[phid] {
+						seen[phid] = true
+						next = append(next, phid)
+					}
+				}
+			}
+			if page.Cursor.After == nil {
+				break
+			}
+			params["after"] = *page.Cursor.After
+		}
+		frontier = next
+	}
+
+	nodes, err := searchRevisions(map[string]any{"phids": slices.Collect(maps.Keys(seen))})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, edges, nil
+}
+
+// renderStack draws the stack as a tree: the revisions depending on no
+// other one first, each followed by the revisions depending on it. A
+// revision depending on several others is drawn once, then referenced.
+func renderStack(nodes []stackNode, edges []stackEdge, selected string) []string {
+	selectedID, _ := strconv.Atoi(strings.TrimPrefix(selected, "D"))
+	byPHID := map[string]stackNode{}
+	for _, node := range nodes {
+		byPHID[node.phid] = node
+	}
+	children := map[string][]stackNode{}
+	hasParent := map[string]bool{}
+	for _, e := range edges {
+		child, ok := byPHID[e.child]
+		if _, parentOK := byPHID[e.parent]; !ok || !parentOK {
+			continue
+		}
+		children[e.parent] = append(children[e.parent], child)
+		hasParent[e.child] = true
+	}
+	byID := func(a, b stackNode) int { return a.id - b.id }
+	var roots []stackNode
+	for _, node := range nodes {
+		if !hasParent[node.phid] {
+			roots = append(roots, node)
+		}
+	}
+	slices.SortFunc(roots, byID)
+
+	var lines []string
+	drawn := map[string]bool{}
+	var draw func(node stackNode, prefix, branch string)
+	draw = func(node stackNode, prefix, branch string) {
+		id := fmt.Sprintf("D%d", node.id)
+		marker := " "
+		if node.id == selectedID {
+			marker = colorRed + "*" + colorReset
+		}
+		if drawn[node.phid] {
+			lines = append(lines, fmt.Sprintf("%s%s%s %s%s%s (see above)", marker, prefix, branch, colorYellow, id, colorReset))
+			return
+		}
+		drawn[node.phid] = true
+		status := node.status
+		if s, ok := stringToStatus[node.status]; ok {
+			status = s.String()
+		}
+		lines = append(lines, fmt.Sprintf("%s%s%s %s%s%s %s  %s", marker, prefix, branch, colorYellow, id, colorReset, status, node.title))
+
+		switch branch {
+		case "├─":
+			prefix += "│  "
+		case "└─":
+			prefix += "   "
+		}
+		kids := children[node.phid]
+		slices.SortFunc(kids, byID)
+		for i, child := range kids {
+			if i == len(kids)-1 {
+				draw(child, prefix, "└─")
+			} else {
+				draw(child, prefix, "├─")
+			}
+		}
+	}
+	for _, root := range roots {
+		draw(root, "", "")
+	}
+	// The revisions of a dependency cycle have no root to be drawn under
+	var cycle []stackNode
+	for _, node := range nodes {
+		if !drawn[node.phid] {
+			cycle = append(cycle, node)
+		}
+	}
+	slices.SortFunc(cycle, byID)
+	for i, node := range cycle {
+		if i == 0 {
+			lines = append(lines, colorRed+"Dependency cycle, each listed under the ones it depends on:"+colorReset)
+		}
+		if !drawn[node.phid] {
+			draw(node, "", "")
+		}
+	}
+	return lines
+}
+
+// loadedStack is the stack of a revision, or why it could not be fetched.
+type loadedStack struct {
+	nodes []stackNode
+	edges []stackEdge
+	err   error
+}
+
+// stackPanel shows the stack of the selected revision.
+type stackPanel struct {
+	*tui.InfoPanel
+	revision *diff
+	// shown is the revision the lines describe.
+	shown string
+	// loaded caches the stack of each revision, fetched by load in the
+	// background when missing.
+	loaded map[string]loadedStack
+	load   func(id string)
+}
+
+func (sp *stackPanel) Draw(active bool) string {
+	id := sp.revision.id
+	if id == "" {
+		return "No revision selected"
+	}
+	loaded, ok := sp.loaded[id]
+	if !ok {
+		sp.load(id)
+		return fmt.Sprintf("Loading the stack of %s…", id)
+	}
+	if id != sp.shown {
+		sp.shown = id
+		if loaded.err != nil {
+			slog.Error("failed to get stack", "revision", id, "error", loaded.err)
+			sp.Lines = []string{fmt.Sprintf("Failed to get the stack of %s: %v", id, loaded.err)}
+		} else {
+			sp.setStack(id, loaded.nodes, loaded.edges)
+		}
+	}
+	return sp.InfoPanel.Draw(active)
+}
+
+// forget drops the cached stacks, fetched again when shown.
+func (sp *stackPanel) forget() {
+	sp.loaded = nil
+	sp.shown = ""
+}
+
+// setStack shows the stack of the revision id.
+func (sp *stackPanel) setStack(id string, nodes []stackNode, edges []stackEdge) {
+	lines := []string{fmt.Sprintf("%s: %d revisions, each listed under the ones it depends on", id, len(nodes)), ""}
+	sp.Lines = append(lines, renderStack(nodes, edges, id)...)
+}
+
+func newStackPanel(name string) stackPanel {
+	return stackPanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+const statusSeparator = "  •  "
+
+// maxRangeWalk bounds the number of commits of a range.
+const maxRangeWalk = 1000
+
+// panelKeys lists the keys available in each panel, by panel title.
+var panelKeys = map[string]string{
+	"Diff from":      "j/k: move  •  d: details  •  g: first parent  •  L: range",
+	"Diff on":        "j/k: move  •  d: details  •  g: first parent  •  L: range",
+	"Range":          "j/k: move  •  m: mark  •  d: details  •  L: two panels",
+	"Diff to update": "j/k: move  •  i: comments  •  s: stack  •  p: interdiff  •  Ctrl+L: land  •  A/U/C/P/V: abandon, reclaim, commandeer, plan changes, request review",
+	"Message":        "Ctrl+E: editor  •  Ctrl+G: generate  •  Ctrl+S: submit",
+	"Options":        "Space: toggle  •  Enter: edit",
+	"Inbox":          "a: accept  •  x: request changes  •  m: comment",
+	"Checks":         "Ctrl+S: submit  •  !: submit anyway",
+	"Scan":           "!: submit anyway  •  u/c: back",
+	"Queue":          "Enter: send the updates  •  Esc: close",
+	"Working tree":   "s: stash  •  f: fold  •  Enter: continue  •  Esc: cancel",
+}
+
+const globalKeys = "u/c/I: mode  •  o: options  •  l: logs  •  R: refresh  •  Tab: switch  •  q: quit"
+
+// rangeCount is the cached number of commits of a diffFrom..diffOn range.
+type rangeCount struct {
+	from, on string
+	count    int
+	err      error
+}
+
+// of returns the number of commits of from..on, counted again when the range changed.
+func (rc *rangeCount) of(from, on *object.Commit) (int, error) {
+	if rc.from != from.Hash.String() || rc.on != on.Hash.String() {
+		count, err := countRange(from, on)
+		*rc = rangeCount{from: from.Hash.String(), on: on.Hash.String(), count: count, err: err}
+	}
+	return rc.count, rc.err
+}
+
+var (
+	errNotAncestor  = errors.New("base not an ancestor")
+	errRangeTooLong = fmt.Errorf("range longer than %d commits", maxRangeWalk)
+)
+
+// countRange counts the commits reachable from on and not from from.
+func countRange(from, on *object.Commit) (int, error) {
+	commits, err := walkRange(from, on)
+	return len(commits), err
+}
+
+// walkRange returns the commits reachable from on and not from from, newest
+// first, like git rev-list from..on. It walks both histories by committer
+// date, marking the ancestors of from, until only them are left to walk. It
+// fails with errNotAncestor when from is not an ancestor of on, and with
+// errRangeTooLong past maxRangeWalk commits.
+func walkRange(from, on *object.Commit) ([]*object.Commit, error) {
+	if from.Hash == on.Hash {
+		return nil, nil
+	}
+	const (
+		fromOn = 1 << iota
+		fromFrom
+	)
+	marks := map[plumbing.Hash]int{on.Hash: fromOn, from.Hash: fromFrom}
+	queue := []*object.Commit{on, from}
+	var walked []*object.Commit
+	for slices.ContainsFunc(queue, func(c *object.Commit) bool { return marks[c.Hash] == fromOn }) {
+		// The newest commit next, the ancestors of from first on a tie
+		// since rebased commits share their date
+		next := 0
+		for i, c := range queue {
+			newer := c.Committer.When.Compare(queue[next].Committer.When)
+			if newer > 0 || newer == 0 && marks[c.Hash]&fromFrom > marks[queue[next].Hash]&fromFrom {
+				next = i
+			}
+		}
+		c := queue[next]
+		queue = slices.Delete(queue, next, next+1)
+		mark := marks[c.Hash]
+		if mark == fromOn {
+			walked = append(walked, c)
+			if len(walked) > maxRangeWalk {
+				return nil, errRangeTooLong
+			}
+		}
+		err := c.Parents().ForEach(func(parent *object.Commit) error {
+			previous, queued := marks[parent.Hash]
+			marks[parent.Hash] = previous | mark
+			if !queued {
+				queue = append(queue, parent)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk the range: %w", err)
+		}
+	}
+	if marks[from.Hash]&fromOn == 0 {
+		return nil, errNotAncestor
+	}
+	// A commit dated before its descendants may be walked before being
+	// marked as an ancestor of from
+	return slices.DeleteFunc(walked, func(c *object.Commit) bool { return marks[c.Hash]&fromFrom != 0 }), nil
+}
+
+// rangeStatus describes the selected range and counts its commits.
+func (h *handler) rangeStatus() (string, error) {
+	if h.diffFromCommit.Commit == nil || h.diffOnCommit.Commit == nil {
+		return "no range", errors.New("no commit selected")
+	}
+	count, err := h.rangeCount.of(h.diffFromCommit.Commit, h.diffOnCommit.Commit)
+	text := fmt.Sprintf("%s..%s", short(h.diffFromCommit.Hash.String()), short(h.diffOnCommit.Hash.String()))
+	if err != nil {
+		return text, err
+	}
+	if count == 0 {
+		return text + " (empty)", errors.New("empty range")
+	}
+	return fmt.Sprintf("%s (%d commits)", text, count), nil
+}
+
+// warnings returns the problems that would make the submission fail or surprise.
+func (h *handler) warnings(rangeErr error) []string {
+	var warnings []string
+	if rangeErr != nil {
+		warnings = append(warnings, rangeErr.Error())
+	}
+	switch h.activeCommand {
+	case Update:
+		if h.diffToUpdate.id == "" {
+			warnings = append(warnings, "no revision selected")
+		}
+		if strings.TrimSpace(*h.updateMsg) == "" {
+			warnings = append(warnings, "empty message")
+		}
+	case Create:
+		if strings.TrimSpace(strings.SplitN(*h.createMsg, "\n", 2)[0]) == "" {
+			warnings = append(warnings, "missing title")
+		}
+	}
+	if h.checks != nil && h.diffOnCommit.Commit != nil && h.checks.hash == h.diffOnCommit.Hash.String() && !h.checks.passed() && !h.checks.overridden {
+		warnings = append(warnings, "checks failed")
+	}
+	return warnings
+}
+
+// target describes what the active command acts on.
+func (h *handler) target() string {
+	switch h.activeCommand {
+	case Update:
+		if h.diffToUpdate.id == "" {
+			return "→ ?"
+		}
+		return "→ " + h.diffToUpdate.id
+	case Create:
+		return "→ new revision"
+	case Inbox:
+		return fmt.Sprintf("%d to review", len(h.panels.inbox.Items))
+	}
+	return ""
+}
+
+// statusLine joins the segments by priority order, dropping the last ones
+// until the line fits in width.
+func statusLine(segments []string, width int) string {
+	for len(segments) > 1 && displayLen(" "+strings.Join(segments, statusSeparator)+" ") > width {
+		segments = segments[:len(segments)-1]
+	}
+	return " " + strings.Join(segments, statusSeparator) + " "
+}
+
+// displayLen returns the number of runes of s, ignoring ANSI escape codes.
+func displayLen(s string) int {
+	count := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			count++
+		}
+	}
+	return count
+}
@@ -2,12 +2,14 @@ package tui
 
 import (
 	"bufio"
-	"fmt"
+	"errors"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"slices"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -25,6 +27,8 @@ type App struct {
 	noDraw              bool     // For testing: skip drawing
 	previousOps         []drawOp // Previous frame operations for double buffering
 	disableDoubleBuffer bool     // Disable double buffering if true
+	postedMu            sync.Mutex
+	posted              []func() // Functions posted to run on the main loop
 }
 
 // NewApp creates a new App instance with the given layout and global handler.
@@ -43,6 +47,28 @@ func NewApp(layout Layout, handler GlobalHandler) *App {
 		rows:   rows,
 		reader: bufio.NewReader(os.Stdin),
 	}
+	return newApp(term, layout, handler)
+}
+
+// NewHeadlessApp creates an App of cols by rows reading its input from in and
+// writing its output to out instead of the terminal, which is left alone.
+// Run returns once in is exhausted. Combined with a Screen, it lets tests
+// script a session and check what is displayed.
+func NewHeadlessApp(layout Layout, handler GlobalHandler, in io.Reader, out io.Writer, cols, rows int) *App {
+	if handler == nil {
+		handler = &DefaultGlobalHandler{}
+	}
+	term := &terminal{
+		cols:     cols,
+		rows:     rows,
+		reader:   bufio.NewReader(in),
+		out:      out,
+		headless: true,
+	}
+	return newApp(term, layout, handler)
+}
+
+func newApp(term *terminal, layout Layout, handler GlobalHandler) *App {
 	app := &App{
 		term:    term,
 		layout:  layout,
@@ -58,14 +84,18 @@ func NewApp(layout Layout, handler GlobalHandler) *App {
 
 func (a *App) layoutPanels(layout Layout) {
 	a.panels = layout.position(0, 0, a.term.cols, a.term.rows-1)
-	if a.activeIdx > len(a.panels) {
-		a.activeIdx = len(a.panels) - 1
+	if a.activeIdx >= len(a.panels) {
+		a.activeIdx = max(0, len(a.panels)-1)
 	}
 }
 
 // Run starts the application's main loop, handling input and rendering until quit.
 // Enables raw mode, processes events, and cleans up on exit.
 func (a *App) Run() {
+	if a.term.headless {
+		a.runHeadless()
+		return
+	}
 	prev, err := enableRawMode()
 	if err == nil {
 		a.term.prevStty = prev
@@ -93,15 +123,27 @@ func (a *App) Run() {
 
 	defer func() {
 		disableRawMode(a.term.prevStty)
-		fmt.Print(ShowCursor)
-		clearScreen()
+		a.term.print(ShowCursor)
+		a.term.clearScreen()
 	}()
 
-	fmt.Print(HideCursor)
+	a.loop()
+}
+
+// runHeadless runs the main loop until the input is exhausted, leaving the
+// last frame on the output.
+func (a *App) runHeadless() {
+	a.loop()
+	a.runPosted()
+	a.draw()
+}
+
+func (a *App) loop() {
+	a.term.print(HideCursor)
 	for _, panel := range a.panels {
 		panel.Update(InputMessage{})
 	}
-	clearScreen()
+	a.term.clearScreen()
 	a.draw()
 
 	for a.running {
@@ -110,7 +152,13 @@ func (a *App) Run() {
 		}) {
 			break
 		}
+		if a.runPosted() {
+			a.draw()
+		}
 		msg, err := a.parseInput()
+		if errors.Is(err, io.EOF) && a.term.headless {
+			return
+		}
 		if err != nil {
 			time.Sleep(10 * time.Millisecond)
 			continue
@@ -130,6 +178,12 @@ func (a *App) SwitchPanel(direction int) {
 	a.callOnPanelSwitch()
 }
 
+// Relayout positions the panels of the layout again. Call it after changing
+// the layout to focus one of its new panels before the next draw.
+func (a *App) Relayout() {
+	a.layoutPanels(a.layout)
+}
+
 // FocusPanel focuses the panel with the given name (Title or index string).
 // Returns true if found and focused.
 func (a *App) FocusPanel(name string) bool {
@@ -143,6 +197,67 @@ func (a *App) FocusPanel(name string) bool {
 	return false
 }
 
+// Suspend hands the terminal back to the user while fn runs, for example to
+// launch an external editor. Raw mode is disabled before fn and re-enabled
+// after it, and the next draw repaints the whole screen.
+func (a *App) Suspend(fn func() error) error {
+	if a.term.headless {
+		err := fn()
+		a.previousOps = nil
+		return err
+	}
+	disableRawMode(a.term.prevStty)
+	a.term.print(ShowCursor)
+	a.term.clearScreen()
+
+	err := fn()
+
+	prev, rawErr := enableRawMode()
+	if rawErr == nil {
+		a.term.prevStty = prev
+	} else {
+		slog.Warn("could not enable raw mode", "error", rawErr)
+	}
+	a.term.print(HideCursor)
+	a.term.clearScreen()
+	a.previousOps = nil
+	return err
+}
+
+// Post schedules fn to run on the main loop, followed by a redraw.
+// It is safe to call from any goroutine, for example to apply the result of
+// a background load. It never blocks: the pending functions are queued
+// without limit.
+func (a *App) Post(fn func()) {
+	a.postedMu.Lock()
+	defer a.postedMu.Unlock()
+	a.posted = append(a.posted, fn)
+}
+
+// runPosted runs the pending posted functions, including the ones they
+// post, and reports if any ran.
+func (a *App) runPosted() bool {
+	ran := false
+	for {
+		a.postedMu.Lock()
+		pending := a.posted
+		a.posted = nil
+		a.postedMu.Unlock()
+		if len(pending) == 0 {
+			return ran
+		}
+		for _, fn := range pending {
+			fn()
+		}
+		ran = true
+	}
+}
+
+// Size returns the terminal size in columns and rows.
+func (a *App) Size() (cols, rows int) {
+	return a.term.cols, a.term.rows
+}
+
 // Stop stops the application by setting running to false.
 func (a *App) Stop() {
 	a.running = false
@@ -97,8 +97,8 @@ func (tp *TextPanel) Update(msg InputMessage) (handled bool, redraw bool) {
 		tp.Text = []rune{}
 		tp.Cursor = 0
 		return true, true
-	case msg.IsChar(' '):
-		// Handle space character
+	case msg.IsChar(' '), msg.IsKey(KeySpace):
+		// Handle space character, parsed as a key from the terminal
 		i := tp.Cursor
 		before := tp.Text[:i]
 		after := tp.Text[i:]
@@ -110,7 +110,7 @@ func (tp *TextPanel) Update(msg InputMessage) (handled bool, redraw bool) {
 		tp.Cursor++
 		return true, true
 	default:
-		if msg.keyType == KeyTypeChar && msg.char >= 32 && msg.char <= 126 {
+		if msg.keyType == KeyTypeChar && msg.char >= 32 && msg.char <= 126 && !msg.HasModifier(ModCtrl) {
 			i := tp.Cursor
 			before := tp.Text[:i]
 			after := tp.Text[i:]
@@ -45,7 +45,8 @@ func (a *App) parseInput() (InputMessage, error) {
 func (a *App) parseEscapeSequence(raw []byte) (InputMessage, error) {
 	next1, err := a.term.reader.ReadByte()
 	if err != nil {
-		return InputMessage{}, err
+		// Nothing follows a lone Esc: the read timed out or the input ended
+		return newKeyMessage(KeyEsc, raw), nil
 	}
 	raw = append(raw, next1)
 
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -448,3 +449,100 @@ func TestShiftTabHandler(t *testing.T) {
 		t.Errorf("Active index should be 2 after Shift+Tab from 0, got %d", app.activeIdx)
 	}
 }
+
+func TestPostRunsOnMainLoop(t *testing.T) {
+	app := newTestApp(&PanelNode{Panel: &PanelBase{}})
+	if app.runPosted() {
+		t.Errorf("runPosted should report nothing ran")
+	}
+
+	done := make(chan struct{})
+	count := 0
+	go func() {
+		app.Post(func() { count++ })
+		app.Post(func() { count++ })
+		close(done)
+	}()
+	<-done
+
+	if !app.runPosted() {
+		t.Errorf("runPosted should report the posted functions ran")
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 posted functions to run, got %d", count)
+	}
+
+	// However many are pending, none is dropped
+	for range 1000 {
+		app.Post(func() { count++ })
+	}
+	app.runPosted()
+	if count != 1002 {
+		t.Errorf("Expected 1002 posted functions to run, got %d", count)
+	}
+}
+
+func TestHeadlessApp(t *testing.T) {
+	count := 0
+	layout := &PanelNode{Panel: &CounterPanel{PanelBase: PanelBase{Title: "Counter", Border: true}, Count: &count}}
+	screen := NewScreen(30, 6)
+	app := NewHeadlessApp(layout, nil, strings.NewReader("++-+"), screen, 30, 6)
+
+	done := make(chan struct{})
+	go func() {
+		app.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run should return once the input is exhausted")
+	}
+
+	if count != 2 {
+		t.Errorf("Expected count 2, got %d", count)
+	}
+	lines := strings.Split(screen.String(), "\n")
+	if !strings.Contains(lines[0], "Counter") {
+		t.Errorf("Expected the title on the first row, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "Count: 2") {
+		t.Errorf("Expected the count on the second row, got %q", lines[1])
+	}
+}
+
+func TestParseEscape(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   Key
+	}{
+		{"lone Esc", "\x1b", KeyEsc},
+		{"arrow", "\x1b[A", KeyUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewHeadlessApp(&PanelNode{Panel: &PanelBase{}}, nil, strings.NewReader(tt.input), io.Discard, 10, 3)
+			msg, err := app.parseInput()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !msg.IsKey(tt.key) {
+				t.Errorf("Expected key %v, got %+v", tt.key, msg)
+			}
+		})
+	}
+}
+
+func TestScreen(t *testing.T) {
+	screen := NewScreen(10, 3)
+	_, _ = screen.Write([]byte(Clear + Home + "\x1b[2;3H" + clrGreen + "ok" + reset + "\x1b[3;1Hé"))
+	// A sequence split across writes is kept until complete.
+	_, _ = screen.Write([]byte("\x1b[1"))
+	_, _ = screen.Write([]byte(";5Hx"))
+
+	expected := "    x\n  ok\né\n"
+	if got := screen.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -93,6 +93,14 @@ func (msg InputMessage) IsChar(char rune) bool {
 	return msg.keyType == KeyTypeChar && msg.char == char
 }
 
+// Char returns the printable character of the message, if it is one
+func (msg InputMessage) Char() (rune, bool) {
+	if msg.keyType != KeyTypeChar || msg.char < 32 || msg.char > 126 || msg.HasModifier(ModCtrl) {
+		return 0, false
+	}
+	return msg.char, true
+}
+
 // IsKey checks if the message is a specific special key
 func (msg InputMessage) IsKey(key Key) bool {
 	return msg.keyType == KeyTypeKey && msg.key == key
@@ -52,6 +52,11 @@ func (pb *PanelBase) GetBase() *PanelBase {
 	return pb
 }
 
+// Size returns the width and height given to the panel by the layout, borders included.
+func (pb *PanelBase) Size() (w, h int) {
+	return pb.w, pb.h
+}
+
 // CursorPosition returns the cursor position for PanelBase.
 // Default implementation shows no cursor.
 func (pb *PanelBase) CursorPosition(active bool) (x, y int, show bool) {
@@ -6,6 +6,7 @@ import (
 )
 
 type drawBuffer struct {
+	term        *terminal
 	operations  []drawOp
 	previousOps []drawOp
 }
@@ -17,6 +18,7 @@ type drawOp struct {
 
 func newDrawBuffer(app *App) *drawBuffer {
 	return &drawBuffer{
+		term:        app.term,
 		operations:  make([]drawOp, 0, 100),
 		previousOps: app.previousOps,
 	}
@@ -29,7 +31,7 @@ func (db *drawBuffer) writeAt(x, y int, content string) {
 func (db *drawBuffer) flush() {
 	changedOps := db.findChangedOperations()
 	for _, op := range changedOps {
-		writeAt(op.x, op.y, op.content)
+		db.term.writeAt(op.x, op.y, op.content)
 	}
 	db.updatePreviousState()
 }
@@ -122,28 +124,28 @@ func (a *App) drawPanel(p Panel, active bool) {
 		if j >= p.GetBase().h {
 			break
 		}
-		writeAt(p.GetBase().x, p.GetBase().y+j, line)
+		a.term.writeAt(p.GetBase().x, p.GetBase().y+j, line)
 	}
 }
 
 func (a *App) drawStatusBar() {
 	status := a.handler.GetStatus()
-	writeAt(0, a.term.rows-1, padRightRuneString(status, a.term.cols))
+	a.term.writeAt(0, a.term.rows-1, padRightRuneString(status, a.term.cols))
 }
 
 func (a *App) drawCursor() {
 	if a.activeIdx >= len(a.panels) {
-		fmt.Print(HideCursor)
+		a.term.print(HideCursor)
 		return
 	}
 	p := a.panels[a.activeIdx]
 	active := true
 	x, y, show := p.CursorPosition(active)
 	if show {
-		fmt.Printf("\x1b[%d;%dH", y+1, x+1)
-		fmt.Print(ShowCursor)
+		a.term.print(fmt.Sprintf("\x1b[%d;%dH", y+1, x+1))
+		a.term.print(ShowCursor)
 	} else {
-		fmt.Print(HideCursor)
+		a.term.print(HideCursor)
 	}
 }
 
@@ -156,11 +158,11 @@ func (a *App) draw() {
 	a.layoutPanels(a.layout)
 
 	if a.disableDoubleBuffer {
-		clearScreen()
+		a.term.clearScreen()
 		a.drawPanels()
 		a.drawStatusBar()
 		a.drawCursor()
-		fmt.Print(reset)
+		a.term.print(reset)
 	} else {
 		buffer := newDrawBuffer(a)
 		a.drawPanelsBuffered(buffer)
@@ -169,7 +171,7 @@ func (a *App) draw() {
 		buffer.flush()
 		a.previousOps = buffer.previousOps
 		a.drawCursor()
-		fmt.Print(reset)
+		a.term.print(reset)
 	}
 }
 
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Screen is a minimal terminal keeping the text written by an App, without
+// its colors. Give it as the output of NewHeadlessApp to check the screen.
+// It understands the cursor moves and clears the App writes.
+type Screen struct {
+	mu    sync.Mutex
+	cols  int
+	rows  int
+	cells [][]rune
+	x, y  int
+	// pending holds an escape sequence or a rune split across writes.
+	pending []byte
+}
+
+// NewScreen creates an empty Screen of cols by rows.
+func NewScreen(cols, rows int) *Screen {
+	s := &Screen{cols: cols, rows: rows}
+	s.clear()
+	return s
+}
+
+func (s *Screen) clear() {
+	s.cells = make([][]rune, s.rows)
+	for y := range s.cells {
+		s.cells[y] = []rune(strings.Repeat(" ", s.cols))
+	}
+}
+
+// Write interprets p as terminal output.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := append(s.pending, p...)
+	s.pending = nil
+	for len(data) > 0 {
+		if data[0] == '\x1b' {
+			n, ok := s.escape(data)
+			if !ok {
+				s.pending = append([]byte(nil), data...)
+				break
+			}
+			data = data[n:]
+			continue
+		}
+		if !utf8.FullRune(data) {
+			s.pending = append([]byte(nil), data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		s.put(r)
+	}
+	return len(p), nil
+}
+
+// escape applies the escape sequence at the start of data and returns its
+// length, or false when it is incomplete.
+func (s *Screen) escape(data []byte) (int, bool) {
+	if len(data) < 2 {
+		return 0, false
+	}
+	if data[1] != '[' {
+		return 2, true
+	}
+	for i := 2; i < len(data); i++ {
+		if data[i] < 0x40 || data[i] > 0x7e {
+			continue
+		}
+		params := strings.Split(strings.TrimPrefix(string(data[2:i]), "?"), ";")
+		arg := func(n, def int) int {
+			if n < len(params) {
+				if v, err := strconv.Atoi(params[n]); err == nil {
+					return v
+				}
+			}
+			return def
+		}
+		switch data[i] {
+		case 'H':
+			s.y, s.x = arg(0, 1)-1, arg(1, 1)-1
+		case 'J':
+			if arg(0, 0) == 2 {
+				s.clear()
+			}
+		case 'K':
+			for x := s.x; s.y >= 0 && s.y < s.rows && x < s.cols; x++ {
+				s.cells[s.y][x] = ' '
+			}
+		}
+		return i + 1, true
+	}
+	return 0, false
+}
+
+func (s *Screen) put(r rune) {
+	switch r {
+	case '\r':
+		s.x = 0
+		return
+	case '\n':
+		s.y++
+		return
+	}
+	width := runeWidth(r)
+	if width == 0 {
+		return
+	}
+	if s.y >= 0 && s.y < s.rows && s.x >= 0 && s.x < s.cols {
+		s.cells[s.y][s.x] = r
+		// The cell covered by a wide rune is skipped by String.
+		if width == 2 && s.x+1 < s.cols {
+			s.cells[s.y][s.x+1] = 0
+		}
+	}
+	s.x += width
+}
+
+// String returns the rows of the screen, without trailing spaces.
+func (s *Screen) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := make([]string, len(s.cells))
+	for y, row := range s.cells {
+		lines[y] = strings.TrimRight(strings.ReplaceAll(string(row), "\x00", ""), " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -3,6 +3,8 @@ package tui
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -13,6 +15,11 @@ type terminal struct {
 	rows     int
 	reader   *bufio.Reader
 	prevStty string
+	// out receives the output, os.Stdout when nil.
+	out io.Writer
+	// headless is set when the terminal is not a tty: raw mode and the
+	// terminal size are left alone.
+	headless bool
 }
 
 func enableRawMode() (prev string, err error) {
@@ -21,7 +28,8 @@ func enableRawMode() (prev string, err error) {
 		return "", err
 	}
 	prev = strings.TrimSpace(string(out))
-	if err := exec.Command("sh", "-c", "stty raw -echo < /dev/tty").Run(); err != nil {
+	// Reads time out after 100ms so that the main loop can run posted functions.
+	if err := exec.Command("sh", "-c", "stty raw -echo min 0 time 1 < /dev/tty").Run(); err != nil {
 		return prev, err
 	}
 	return prev, nil
@@ -49,11 +57,19 @@ func getTermSize() (cols, rows int, err error) {
 	return 80, 24, fmt.Errorf("unexpected stty size output")
 }
 
-func writeAt(x, y int, s string) {
-	fmt.Printf("\x1b[%d;%dH%s", y+1, x+1, s)
+func (t *terminal) print(s string) {
+	out := t.out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, _ = io.WriteString(out, s)
+}
+
+func (t *terminal) writeAt(x, y int, s string) {
+	t.print(fmt.Sprintf("\x1b[%d;%dH%s", y+1, x+1, s))
 }
 
-func clearScreen() {
-	fmt.Print(Clear)
-	fmt.Print(Home)
+func (t *terminal) clearScreen() {
+	t.print(Clear)
+	t.print(Home)
 }
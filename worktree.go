@@ -0,0 +1,166 @@
+package main
+
+import (
+	"app/tui"
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+)
+
+// worktreeFile is a modified or untracked file of the working tree.
+type worktreeFile struct {
+	path string
+	// code is the two letter status of git status --short, like " M" or "??".
+	code string
+}
+
+func (f worktreeFile) String() string {
+	color := colorYellow
+	if f.code == "??" {
+		color = colorRed
+	}
+	return fmt.Sprintf("%s%s%s %s", color, f.code, colorReset, f.path)
+}
+
+// dirtyFiles returns the modified and untracked files of the working tree, sorted by path.
+func dirtyFiles() ([]worktreeFile, error) {
+	repo, err := openRepo()
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	var files []worktreeFile
+	for path, s := range status {
+		if s.Staging == git.Unmodified && s.Worktree == git.Unmodified {
+			continue
+		}
+		files = append(files, worktreeFile{path: path, code: string([]byte{byte(s.Staging), byte(s.Worktree)})})
+	}
+	slices.SortFunc(files, func(a, b worktreeFile) int { return cmp.Compare(a.path, b.path) })
+	return files, nil
+}
+
+// runGit runs git with args in the current repository and returns its combined output.
+func runGit(args ...string) ([]byte, error) {
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return output, fmt.Errorf("failed to run 'git %s': %w: %s", args[0], err, bytes.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// stashWorktree stashes the changes and the untracked files of the working tree.
+func stashWorktree() error {
+	_, err := runGit("stash", "push", "--include-untracked", "--message", "bow: set aside before submitting")
+	return err
+}
+
+// restoreStash pops the stash made by stashWorktree.
+func restoreStash() error {
+	_, err := runGit("stash", "pop")
+	return err
+}
+
+// foldChanges commits the changes of the tracked files as a fixup of hash
+// and squashes it into hash, rebasing the commits after it, so that the
+// changes are part of the ranges ending at hash. Untracked files are left
+// alone. It returns the new hash of the commit.
+func foldChanges(hash string) (string, error) {
+	if _, err := runGit("merge-base", "--is-ancestor", hash, "HEAD"); err != nil {
+		return "", fmt.Errorf("%s is not in the checked out branch", short(hash))
+	}
+	output, err := runGit("rev-list", "--count", hash+"..HEAD")
+	if err != nil {
+		return "", err
+	}
+	after := strings.TrimSpace(string(output))
+	if _, err := runGit("commit", "--all", "--no-verify", "--fixup", hash); err != nil {
+		return "", err
+	}
+	base := hash + "^"
+	if _, err := runGit("rev-parse", "--verify", "--quiet", base); err != nil {
+		base = "--root"
+	}
+	rebase := exec.Command("git", "rebase", "--interactive", "--autosquash", "--rebase-merges", base)
+	rebase.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR=:")
+	if output, err := rebase.CombinedOutput(); err != nil {
+		// Leave the history as it was, with the changes back in the working tree
+		_, _ = runGit("rebase", "--abort")
+		_, _ = runGit("reset", "HEAD~1")
+		return "", fmt.Errorf("failed to squash the changes into %s: %w: %s", short(hash), err, bytes.TrimSpace(output))
+	}
+	output, err = runGit("rev-parse", "HEAD~"+after)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// worktreeChoice is what to do with the changes of a dirty working tree.
+type worktreeChoice int
+
+const (
+	worktreeCancel worktreeChoice = iota
+	worktreeStash
+	worktreeFold
+	worktreeContinue
+)
+
+// worktreePanel lists the changes of the working tree and asks what to do with them.
+type worktreePanel struct {
+	*tui.InfoPanel
+	onChoice func(choice worktreeChoice)
+}
+
+func (wp *worktreePanel) Update(msg tui.InputMessage) (handled bool, redraw bool) {
+	choice := worktreeCancel
+	switch {
+	case msg.IsChar('s'):
+		choice = worktreeStash
+	case msg.IsChar('f'):
+		choice = worktreeFold
+	case msg.IsKey(tui.KeyEnter):
+		choice = worktreeContinue
+	case msg.IsKey(tui.KeyEsc):
+	default:
+		return wp.InfoPanel.Update(msg)
+	}
+	if wp.onChoice != nil {
+		wp.onChoice(choice)
+	}
+	return true, true
+}
+
+// ask lists files and calls onChoice with the choice of the user.
+func (wp *worktreePanel) ask(files []worktreeFile, onChoice func(choice worktreeChoice)) {
+	lines := []string{fmt.Sprintf("%d uncommitted changes, arc may refuse them or send them:", len(files)), ""}
+	for _, f := range files {
+		lines = append(lines, "  "+f.String())
+	}
+	wp.Lines = append(lines, "", "s: stash until submitted  •  f: fold the tracked changes into Diff on  •  Enter: continue anyway  •  Esc: cancel")
+	wp.onChoice = onChoice
+}
+
+func newWorktreePanel(name string) worktreePanel {
+	return worktreePanel{
+		InfoPanel: &tui.InfoPanel{
+			PanelBase: tui.PanelBase{
+				Title:  name,
+				Border: true,
+			},
+		},
+	}
+}